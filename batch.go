@@ -0,0 +1,475 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+// MaxBatchSize is the largest number of requests the Graph API accepts in one batch.
+const MaxBatchSize = 50
+
+var (
+	ErrBatchItemSkipped = errors.New("batch item was not executed")
+	ErrBatchDependency  = errors.New("invalid batch dependency")
+)
+
+type (
+	// Batch queues requests and sends them to the batch endpoint of the Graph API, up to
+	// MaxBatchSize per call. Requests that depend on each other, through DependsOn or a
+	// BatchReference, are always sent in the same call. A Batch is not safe for concurrent
+	// use.
+	//
+	//	batch := client.Batch()
+	//	results := make([]*whatsapp.MediaInformation, len(ids))
+	//	for i, id := range ids {
+	//		results[i] = new(whatsapp.MediaInformation)
+	//		batch.Add(whttp.MakeRequest(whttp.WithMethod(http.MethodGet),
+	//			whttp.WithRequestContext(&whttp.RequestContext{Route: whttp.MediaRoute(id)})), results[i])
+	//	}
+	//	if err := batch.Send(ctx); err != nil {
+	//		return err
+	//	}
+	Batch struct {
+		client *Client
+		items  []*BatchResult
+	}
+
+	// BatchResult is the outcome of one request of a Batch. It is filled in by Batch.Send.
+	// Err is a *whttp.ResponseError when the API returned an error for the request and
+	// ErrBatchItemSkipped when it was not executed because a request it depends on failed.
+	BatchResult struct {
+		StatusCode int
+		Headers    http.Header
+		Body       json.RawMessage
+		Err        error
+
+		name      string
+		dependsOn string
+		omit      bool
+		request   *whttp.Request
+		response  any
+	}
+
+	// BatchItemOption configures a request added to a Batch.
+	BatchItemOption func(result *BatchResult)
+
+	batchOperation struct {
+		Method                string `json:"method"`
+		RelativeURL           string `json:"relative_url"`
+		Body                  string `json:"body,omitempty"`
+		Name                  string `json:"name,omitempty"`
+		DependsOn             string `json:"depends_on,omitempty"`
+		OmitResponseOnSuccess *bool  `json:"omit_response_on_success,omitempty"`
+	}
+
+	batchResponse struct {
+		Code    int    `json:"code"`
+		Body    string `json:"body"`
+		Headers []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+	}
+)
+
+// WithBatchName names the request so that other requests can depend on it or reference
+// its result.
+func WithBatchName(name string) BatchItemOption {
+	return func(result *BatchResult) {
+		result.name = name
+	}
+}
+
+// WithBatchDependsOn makes the request run only after the named request succeeded.
+func WithBatchDependsOn(name string) BatchItemOption {
+	return func(result *BatchResult) {
+		result.dependsOn = name
+	}
+}
+
+// WithBatchOmitResponse sets omit_response_on_success on a named request. By default the
+// responses of named requests are returned, with omit set to true the API leaves them out
+// on success and their BatchResult stays empty.
+func WithBatchOmitResponse(omit bool) BatchItemOption {
+	return func(result *BatchResult) {
+		result.omit = omit
+	}
+}
+
+// BatchReference returns a reference to the result of the named request that can be used
+// in the route, query or body of a later request of the same batch, for example
+// BatchReference("templates", "$.data.*.id").
+func BatchReference(name, jsonPath string) string {
+	return fmt.Sprintf("{result=%s:%s}", name, jsonPath)
+}
+
+var batchReferencePattern = regexp.MustCompile(`\{result=([^:}]+):`)
+
+// Batch returns an empty Batch that uses the configuration of the client.
+func (client *Client) Batch() *Batch {
+	return &Batch{client: client}
+}
+
+// Add queues request and returns its result. When the request succeeds its response is
+// decoded into response, which may be nil. Only the route, method, query, form and payload
+// of the request are used: every request of the batch is sent with the token of the client.
+func (batch *Batch) Add(request *whttp.Request, response any, opts ...BatchItemOption) *BatchResult {
+	result := &BatchResult{request: request, response: response}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(result)
+		}
+	}
+	batch.items = append(batch.items, result)
+
+	return result
+}
+
+// Len returns the number of queued requests.
+func (batch *Batch) Len() int {
+	return len(batch.items)
+}
+
+// Send sends the queued requests and empties the batch. Errors of single requests are
+// reported in their BatchResult, Send only returns an error when a whole call failed or
+// the batch is not valid, in which case the results of the requests that were not sent
+// hold the same error.
+func (batch *Batch) Send(ctx context.Context, opts ...CallOption) error {
+	ctx = batch.client.withCallOptions(ctx, opts)
+	items := batch.items
+	batch.items = nil
+
+	chunks, err := batchChunks(batch.client.bc.codec, items)
+	if err != nil {
+		setBatchError(items, err)
+
+		return err
+	}
+
+	for i, chunk := range chunks {
+		if err = batch.send(ctx, chunk); err != nil {
+			err = fmt.Errorf("batch %d of %d: %w", i+1, len(chunks), err)
+			for _, rest := range chunks[i:] {
+				setBatchError(rest, err)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (batch *Batch) send(ctx context.Context, items []*BatchResult) error {
+	config := batch.client.config()
+	operations := make([]*batchOperation, len(items))
+	for i, item := range items {
+		operation, err := newBatchOperation(batch.client.bc.codec, config, item)
+		if err != nil {
+			return fmt.Errorf("request %d: %w", i, err)
+		}
+		operations[i] = operation
+	}
+
+	encoded, err := codec.OrDefault(batch.client.bc.codec).Marshal(operations)
+	if err != nil {
+		return fmt.Errorf("encode batch: %w", err)
+	}
+
+	request := &whttp.Request{
+		Context: &whttp.RequestContext{
+			TokenSource:       batch.client.tokens,
+			Name:              "batch",
+			BaseURL:           config.BaseURL,
+			ApiVersion:        config.Version,
+			BusinessAccountID: config.BusinessAccountID,
+			Route:             whttp.RootRoute(),
+		},
+		Method: http.MethodPost,
+		Bearer: config.AccessToken,
+		Form:   map[string]string{"batch": string(encoded), "include_headers": "true"},
+	}
+
+	var responses []*batchResponse
+	if err = batch.client.bc.do(ctx, request, &responses); err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		if i >= len(responses) || responses[i] == nil {
+			if item.name == "" || !item.omit {
+				item.Err = ErrBatchItemSkipped
+			}
+
+			continue
+		}
+		item.decode(batch.client.bc.codec, responses[i])
+	}
+
+	return nil
+}
+
+func (result *BatchResult) decode(c codec.Codec, response *batchResponse) {
+	result.StatusCode = response.Code
+	result.Body = json.RawMessage(response.Body)
+	result.Headers = make(http.Header, len(response.Headers))
+	for _, header := range response.Headers {
+		result.Headers.Add(header.Name, header.Value)
+	}
+
+	if response.Code < http.StatusOK || response.Code > http.StatusIMUsed {
+		responseErr := whttp.NewResponseErrorWithCodec(c,
+			&http.Response{StatusCode: response.Code, Header: result.Headers}, []byte(response.Body))
+		if result.request != nil && result.request.Context != nil {
+			responseErr.RequestName = result.request.Context.Name
+			responseErr.Method = result.request.Method
+		}
+		result.Err = responseErr
+
+		return
+	}
+
+	if result.response != nil && len(result.Body) != 0 {
+		if err := codec.OrDefault(c).Unmarshal(result.Body, result.response); err != nil {
+			result.Err = fmt.Errorf("decode batch response: %w", err)
+		}
+	}
+}
+
+// newBatchOperation converts a request to an operation of the batch. The relative URL is
+// the URL of the request below the base URL and the version of the client.
+func newBatchOperation(c codec.Codec, config *Config, item *BatchResult) (*batchOperation, error) {
+	if item.request == nil || item.request.Context == nil {
+		return nil, fmt.Errorf("%w: request or request context should not be nil", whttp.ErrInvalidRequestValue)
+	}
+
+	reqCtx := *item.request.Context
+	reqCtx.BaseURL, reqCtx.ApiVersion = config.BaseURL, config.Version
+	if reqCtx.Route == nil && reqCtx.PhoneNumberID == "" {
+		reqCtx.PhoneNumberID = config.PhoneNumberID
+	}
+
+	requestURL, err := whttp.RequestURLFromContext(&reqCtx)
+	if err != nil {
+		return nil, fmt.Errorf("batch request url: %w", err)
+	}
+
+	prefix := strings.TrimSuffix(config.BaseURL, "/") + "/" + config.Version + "/"
+	relative, found := strings.CutPrefix(requestURL, prefix)
+	if !found {
+		return nil, fmt.Errorf("%w: %q is not below %q", whttp.ErrInvalidRequestValue, requestURL, prefix)
+	}
+
+	if len(item.request.Query) > 0 {
+		query := url.Values{}
+		for key, value := range item.request.Query {
+			query.Set(key, value)
+		}
+		relative += "?" + query.Encode()
+	}
+
+	// keep the result references readable for the API
+	relative = strings.NewReplacer("%7B", "{", "%7D", "}", "%3A", ":", "%24", "$", "%2A", "*").Replace(relative)
+
+	body, err := batchBody(c, item.request)
+	if err != nil {
+		return nil, err
+	}
+
+	method := item.request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	operation := &batchOperation{
+		Method:      method,
+		RelativeURL: relative,
+		Body:        body,
+		Name:        item.name,
+		DependsOn:   item.dependsOn,
+	}
+	if item.name != "" {
+		operation.OmitResponseOnSuccess = &item.omit
+	}
+
+	return operation, nil
+}
+
+// batchBody returns the url encoded body of a batch operation. A JSON payload is encoded
+// with c and sent as one parameter per top level field.
+func batchBody(c codec.Codec, request *whttp.Request) (string, error) {
+	c = codec.OrDefault(c)
+	values := url.Values{}
+	switch {
+	case request.Form != nil:
+		for key, value := range request.Form {
+			values.Set(key, value)
+		}
+	case request.Payload != nil:
+		switch payload := request.Payload.(type) {
+		case string:
+			return payload, nil
+		case []byte:
+			return string(payload), nil
+		}
+
+		encoded, err := c.Marshal(request.Payload)
+		if err != nil {
+			return "", fmt.Errorf("encode batch body: %w", err)
+		}
+
+		var fields map[string]json.RawMessage
+		if err = c.Unmarshal(encoded, &fields); err != nil {
+			return "", fmt.Errorf("%w: batch payload must be a json object", whttp.ErrInvalidRequestValue)
+		}
+
+		for key, raw := range fields {
+			var text string
+			if c.Unmarshal(raw, &text) != nil {
+				text = string(raw)
+			}
+			values.Set(key, text)
+		}
+	default:
+		return "", nil
+	}
+
+	return values.Encode(), nil
+}
+
+// batchChunks splits the items into calls of at most MaxBatchSize requests, keeping the
+// requests that depend on each other together and in order.
+func batchChunks(c codec.Codec, items []*BatchResult) ([][]*BatchResult, error) {
+	// group the items with union find over their dependencies
+	parent := make([]int, len(items))
+	named := map[string]int{}
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+
+		return parent[i]
+	}
+
+	for i, item := range items {
+		for _, name := range item.dependencies(c) {
+			j, ok := named[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: request %d depends on %q which is not an earlier request",
+					ErrBatchDependency, i, name)
+			}
+			parent[find(i)] = find(j)
+		}
+
+		if item.name != "" {
+			named[item.name] = i
+		}
+	}
+
+	var (
+		groups [][]*BatchResult
+		index  = map[int]int{}
+	)
+	for i, item := range items {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], item)
+	}
+
+	var chunks [][]*BatchResult
+	for _, group := range groups {
+		if len(group) > MaxBatchSize {
+			return nil, fmt.Errorf("%w: %d dependent requests do not fit in one batch",
+				ErrBatchDependency, len(group))
+		}
+
+		last := len(chunks) - 1
+		if last < 0 || len(chunks[last])+len(group) > MaxBatchSize {
+			chunks = append(chunks, nil)
+			last++
+		}
+		chunks[last] = append(chunks[last], group...)
+	}
+
+	return chunks, nil
+}
+
+// dependencies returns the names of the requests the item depends on.
+func (result *BatchResult) dependencies(c codec.Codec) []string {
+	var names []string
+	if result.dependsOn != "" {
+		names = append(names, result.dependsOn)
+	}
+
+	if result.request == nil {
+		return names
+	}
+
+	var text strings.Builder
+	if result.request.Context != nil && result.request.Context.Route != nil {
+		text.WriteString(result.request.Context.Route.ID)
+		text.WriteString(strings.Join(result.request.Context.Route.Edges, "/"))
+	}
+	for _, value := range result.request.Query {
+		text.WriteString(value)
+	}
+	for _, value := range result.request.Form {
+		text.WriteString(value)
+	}
+	if body, err := batchBody(c, &whttp.Request{Payload: result.request.Payload}); err == nil {
+		decoded, _ := url.QueryUnescape(body)
+		text.WriteString(decoded)
+	}
+
+	for _, match := range batchReferencePattern.FindAllStringSubmatch(text.String(), -1) {
+		names = append(names, match[1])
+	}
+
+	return names
+}
+
+func setBatchError(items []*BatchResult, err error) {
+	for _, item := range items {
+		if item.Err == nil && item.StatusCode == 0 {
+			item.Err = err
+		}
+	}
+}
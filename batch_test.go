@@ -0,0 +1,172 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+func TestBatchSend(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/v16.0/" || r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		var operations []*batchOperation
+		if err := json.Unmarshal([]byte(r.FormValue("batch")), &operations); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		responses := make([]any, len(operations))
+		for i, op := range operations {
+			switch op.Method + " " + op.RelativeURL {
+			case "GET media-1":
+				responses[i] = map[string]any{"code": 200, "body": `{"id":"media-1","mime_type":"image/png"}`}
+			case "GET 5678/message_templates?fields=id&limit=1":
+				if op.Name != "templates" || *op.OmitResponseOnSuccess {
+					w.WriteHeader(http.StatusBadRequest)
+
+					return
+				}
+				responses[i] = map[string]any{
+					"code": 200, "body": `{"data":[{"id":"t1"}]}`,
+					"headers": []map[string]string{{"name": "X-Business-Use-Case-Usage", "value": "{}"}},
+				}
+			case "GET {result=templates:$.data.*.id}":
+				responses[i] = map[string]any{"code": 200, "body": `{"id":"t1","name":"welcome"}`}
+			case "POST 1234/message_qrdls/A":
+				if op.Body != "prefilled_message=hi" {
+					w.WriteHeader(http.StatusBadRequest)
+
+					return
+				}
+				responses[i] = map[string]any{"code": 400, "body": `{"error":{"message":"invalid code","code":100}}`}
+			default:
+				responses[i] = nil
+			}
+		}
+		_ = json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(route *whttp.Route, query map[string]string) *whttp.Request {
+		return &whttp.Request{Context: &whttp.RequestContext{Route: route}, Method: http.MethodGet, Query: query}
+	}
+
+	var (
+		media    MediaInformation
+		template struct {
+			Name string `json:"name"`
+		}
+		batch = client.Batch()
+	)
+
+	mediaResult := batch.Add(get(whttp.MediaRoute("media-1"), nil), &media)
+	templates := batch.Add(get(whttp.BusinessAccountRoute("5678", "message_templates"),
+		map[string]string{"fields": "id", "limit": "1"}), nil, WithBatchName("templates"))
+	templateResult := batch.Add(get(whttp.TemplateRoute(BatchReference("templates", "$.data.*.id")), nil),
+		&template)
+	qrResult := batch.Add(&whttp.Request{
+		Context: &whttp.RequestContext{Route: whttp.QRCodeRoute("1234", "A")},
+		Method:  http.MethodPost,
+		Payload: map[string]string{"prefilled_message": "hi"},
+	}, nil)
+	skipped := batch.Add(get(whttp.MediaRoute("media-2"), nil), nil, WithBatchDependsOn("templates"))
+
+	if err = batch.Send(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	if mediaResult.Err != nil || media.MimeType != "image/png" {
+		t.Errorf("media = %+v, %v", media, mediaResult.Err)
+	}
+
+	if templates.Err != nil || templates.Headers.Get("X-Business-Use-Case-Usage") != "{}" {
+		t.Errorf("templates result = %+v", templates)
+	}
+
+	if templateResult.Err != nil || template.Name != "welcome" {
+		t.Errorf("template = %+v, %v", template, templateResult.Err)
+	}
+
+	var responseErr *whttp.ResponseError
+	if !errors.As(qrResult.Err, &responseErr) || responseErr.Code != http.StatusBadRequest ||
+		responseErr.Err.Code != 100 {
+		t.Errorf("qr code error = %v", qrResult.Err)
+	}
+
+	if !errors.Is(skipped.Err, ErrBatchItemSkipped) {
+		t.Errorf("expected ErrBatchItemSkipped, got %v", skipped.Err)
+	}
+
+	if calls != 1 || batch.Len() != 0 {
+		t.Errorf("calls = %d, queued = %d, want 1 and 0", calls, batch.Len())
+	}
+}
+
+func TestBatchChunks(t *testing.T) {
+	t.Parallel()
+	request := func(route *whttp.Route) *whttp.Request {
+		return &whttp.Request{Context: &whttp.RequestContext{Route: route}}
+	}
+
+	items := make([]*BatchResult, 0, 51)
+	for i := 0; i < 49; i++ {
+		items = append(items, &BatchResult{request: request(whttp.MediaRoute(fmt.Sprint(i)))})
+	}
+	items = append(items,
+		&BatchResult{name: "first", request: request(whttp.MediaRoute("a"))},
+		&BatchResult{request: request(whttp.MediaRoute(BatchReference("first", "$.id")))})
+
+	chunks, err := batchChunks(nil, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the dependent requests do not fit in the first call and move together to the second
+	if len(chunks) != 2 || len(chunks[0]) != 49 || len(chunks[1]) != 2 {
+		t.Errorf("chunk sizes are wrong: %d chunks", len(chunks))
+	}
+
+	_, err = batchChunks(nil, []*BatchResult{{dependsOn: "missing", request: request(whttp.MediaRoute("a"))}})
+	if !errors.Is(err, ErrBatchDependency) {
+		t.Errorf("expected ErrBatchDependency, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"time"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+type (
+	// CallOption changes a single call of a Client method, without changing the Client.
+	//
+	//	client.SendText(ctx, recipient, message,
+	//		whatsapp.WithCallTimeout(5*time.Second),
+	//		whatsapp.WithCallMetadata("correlation_id", id),
+	//	)
+	CallOption func(*callOptions)
+
+	callOptions struct {
+		timeout  time.Duration
+		deadline time.Time
+		version  string
+		headers  map[string]string
+		metadata map[string]string
+	}
+
+	callOptionsKey struct{}
+
+	clientKey struct{}
+)
+
+// WithCallTimeout bounds the whole call, including the retries made by middleware and the
+// other requests made by the Client method. The deadline is set once when the method is
+// called, every page of an iterator gets its own.
+func WithCallTimeout(timeout time.Duration) CallOption {
+	return func(options *callOptions) {
+		options.timeout = timeout
+	}
+}
+
+// WithCallAPIVersion makes the call use another API version, for example "v21.0", while
+// Config.Version stays the same for the other calls.
+func WithCallAPIVersion(version string) CallOption {
+	return func(options *callOptions) {
+		options.version = version
+	}
+}
+
+// WithCallHeader adds a header to the request.
+func WithCallHeader(key, value string) CallOption {
+	return func(options *callOptions) {
+		if options.headers == nil {
+			options.headers = make(map[string]string)
+		}
+		options.headers[key] = value
+	}
+}
+
+// WithCallMetadata adds an entry to the whttp.Request Metadata of the call, where hooks and
+// operation middleware can read it, for example a correlation id.
+func WithCallMetadata(key, value string) CallOption {
+	return func(options *callOptions) {
+		if options.metadata == nil {
+			options.metadata = make(map[string]string)
+		}
+		options.metadata[key] = value
+	}
+}
+
+// withCallOptions returns a context carrying the client making the call and the call options,
+// see withCallOptions.
+func (client *Client) withCallOptions(ctx context.Context, opts []CallOption) context.Context {
+	return withCallOptions(context.WithValue(ctx, clientKey{}, client), opts)
+}
+
+// clientFromContext returns the client making the call, so that the hooks of a BaseClient
+// shared by several clients can tell them apart.
+func clientFromContext(ctx context.Context) (*Client, bool) {
+	client, ok := ctx.Value(clientKey{}).(*Client)
+
+	return client, ok && client != nil
+}
+
+// withCallOptions returns a context carrying the call options, merged with the ones already
+// in ctx when a Client method calls another one.
+func withCallOptions(ctx context.Context, opts []CallOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+
+	options := &callOptions{}
+	if parent, ok := ctx.Value(callOptionsKey{}).(*callOptions); ok {
+		*options = *parent
+		options.headers = copyStringMap(parent.headers)
+		options.metadata = copyStringMap(parent.metadata)
+	}
+
+	options.timeout = 0
+	for _, opt := range opts {
+		if opt != nil {
+			opt(options)
+		}
+	}
+
+	if options.timeout > 0 {
+		options.deadline = time.Now().Add(options.timeout)
+	}
+
+	return context.WithValue(ctx, callOptionsKey{}, options)
+}
+
+// applyCallOptions returns a copy of request changed by the call options in ctx, and the
+// context to make the call with.
+func applyCallOptions(ctx context.Context, request *whttp.Request) (context.Context, context.CancelFunc,
+	*whttp.Request,
+) {
+	options, ok := ctx.Value(callOptionsKey{}).(*callOptions)
+	if !ok || request == nil {
+		return ctx, func() {}, request
+	}
+
+	changed := *request
+	if options.version != "" && request.Context != nil {
+		rctx := *request.Context
+		rctx.ApiVersion = options.version
+		changed.Context = &rctx
+	}
+
+	if len(options.headers) > 0 {
+		changed.Headers = copyStringMap(request.Headers)
+		for key, value := range options.headers {
+			changed.Headers[key] = value
+		}
+	}
+
+	if len(options.metadata) > 0 {
+		changed.Metadata = copyStringMap(request.Metadata)
+		for key, value := range options.metadata {
+			changed.Metadata[key] = value
+		}
+	}
+
+	if !options.deadline.IsZero() {
+		ctx, cancel := context.WithDeadline(ctx, options.deadline)
+
+		return ctx, cancel, &changed
+	}
+
+	return ctx, func() {}, &changed
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+
+	return result
+}
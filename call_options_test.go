@@ -0,0 +1,116 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCallOptions(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v16.0/1234/message_qrdls/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		if r.URL.Path != "/v21.0/1234/message_qrdls/code" || r.Header.Get("X-Request-Id") != "abc" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	var metadata map[string]string
+	capture := func(next OperationHandler) OperationHandler {
+		return OperationHandlerFunc(func(ctx context.Context, op *Operation) error {
+			metadata = op.Request.Metadata
+
+			return next.Handle(ctx, op)
+		})
+	}
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"},
+		WithBaseClient(NewBaseClient(WithOperationMiddleware(capture))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.DeleteQRCode(context.TODO(), "code",
+		WithCallAPIVersion("v21.0"),
+		WithCallHeader("X-Request-Id", "abc"),
+		WithCallMetadata("correlation_id", "abc"),
+	)
+	if err != nil {
+		t.Fatalf("DeleteQRCode() error = %v", err)
+	}
+
+	if metadata["correlation_id"] != "abc" {
+		t.Errorf("metadata = %v, want a correlation_id", metadata)
+	}
+
+	if client.Config().Version != "v16.0" {
+		t.Errorf("the call option changed the configured version to %s", client.Config().Version)
+	}
+
+	_, err = client.DeleteQRCode(context.TODO(), "slow", WithCallTimeout(20*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got %v", err)
+	}
+}
+
+func TestCallTimeoutSpansRequests(t *testing.T) {
+	t.Parallel()
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(60 * time.Millisecond)
+		if r.URL.Path == "/file" {
+			_, _ = w.Write([]byte("content"))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"id":"media","url":"` + serverURL + `/file"}`))
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each request fits in the timeout, the media lookup and the download together do not
+	_, err = client.DownloadMedia(context.TODO(), "media", 0, WithCallTimeout(100*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got %v", err)
+	}
+
+	if _, err = client.DownloadMedia(context.TODO(), "media", 0, WithCallTimeout(time.Second)); err != nil {
+		t.Errorf("DownloadMedia() error = %v", err)
+	}
+}
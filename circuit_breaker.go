@@ -0,0 +1,61 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/models"
+)
+
+// CircuitBreakerMiddleware returns a SendMiddleware that sends messages through breaker,
+// one circuit per route and phone number ID. While a circuit is open messages are not
+// sent and the error matches whttp.ErrCircuitOpen, so that they can be queued instead:
+//
+//	breaker := whttp.NewCircuitBreaker(whttp.WithCircuitStateChange(
+//		func(key string, from, to whttp.CircuitState) {
+//			slog.Warn("whatsapp circuit", "key", key, "from", from, "to", to)
+//		}))
+//	base := whatsapp.NewBaseClient(whatsapp.WithBaseClientMiddleware(whatsapp.CircuitBreakerMiddleware(breaker)))
+//
+//	_, err := base.Send(ctx, rtx, message)
+//	if errors.Is(err, whttp.ErrCircuitOpen) {
+//		queue.Push(message)
+//	}
+//
+// Use whttp.WithCircuitBreaker instead to guard every call of a whttp.Client.
+func CircuitBreakerMiddleware(breaker *whttp.CircuitBreaker) SendMiddleware {
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, req *whttp.RequestContext,
+			message *models.Message,
+		) (*ResponseMessage, error) {
+			var response *ResponseMessage
+			err := breaker.Execute(whttp.CircuitKey(req), func() error {
+				var err error
+				response, err = next.Send(ctx, req, message)
+
+				return err
+			})
+
+			return response, err //nolint:wrapcheck
+		})
+	}
+}
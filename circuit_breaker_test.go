@@ -0,0 +1,62 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/models"
+)
+
+func TestCircuitBreakerMiddleware(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	failing := SenderFunc(func(context.Context, *whttp.RequestContext, *models.Message) (*ResponseMessage, error) {
+		calls++
+
+		return nil, &whttp.ResponseError{Code: http.StatusBadGateway}
+	})
+
+	breaker := whttp.NewCircuitBreaker(whttp.WithCircuitConsecutiveFailures(2))
+	sender := WrapSender(failing, CircuitBreakerMiddleware(breaker))
+	first := &whttp.RequestContext{PhoneNumberID: "1", Route: whttp.PhoneNumberRoute("1", "messages")}
+	second := &whttp.RequestContext{PhoneNumberID: "2", Route: whttp.PhoneNumberRoute("2", "messages")}
+
+	for i := 0; i < 3; i++ {
+		_, _ = sender.Send(context.TODO(), first, &models.Message{})
+	}
+
+	if _, err := sender.Send(context.TODO(), first, &models.Message{}); !errors.Is(err, whttp.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	// the circuit of another phone number is still closed
+	if _, err := sender.Send(context.TODO(), second, &models.Message{}); errors.Is(err, whttp.ErrCircuitOpen) {
+		t.Errorf("expected the call to be made, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/piusalfred/whatsapp/pkg/qrcode"
+)
+
+// ClickToChatBaseURL is the base of the links that open a chat with a phone number.
+const ClickToChatBaseURL = "https://wa.me/"
+
+const (
+	minPhoneNumberDigits = 7
+	maxPhoneNumberDigits = 15
+)
+
+var (
+	ErrInvalidPhoneNumber = errors.New("invalid phone number")
+	ErrUnsupportedFormat  = errors.New("unsupported image format")
+)
+
+// ClickToChat builds click to chat links of the form https://wa.me/<number>?text=<text>.
+// The number is written in international format without the leading plus sign, zeros or
+// brackets, for example 255700000000.
+//
+//	link, err := whatsapp.NewClickToChat("+255 700 000 000").Text("Habari yako?").URL()
+type ClickToChat struct {
+	number string
+	text   string
+}
+
+// NewClickToChat returns a builder for a link to the phone number. Spaces, dashes, dots,
+// brackets and a leading plus sign are removed before the number is validated.
+func NewClickToChat(number string) *ClickToChat {
+	return &ClickToChat{number: number}
+}
+
+// Text sets the message prefilled in the chat.
+func (link *ClickToChat) Text(text string) *ClickToChat {
+	link.text = text
+
+	return link
+}
+
+// URL returns the link, or an error if the phone number is not valid.
+func (link *ClickToChat) URL() (string, error) {
+	number, err := NormalizePhoneNumber(link.number)
+	if err != nil {
+		return "", err
+	}
+
+	result := ClickToChatBaseURL + number
+	if link.text != "" {
+		// wa.me expects spaces encoded as %20 rather than +
+		result += "?text=" + strings.ReplaceAll(url.QueryEscape(link.text), "+", "%20")
+	}
+
+	return result, nil
+}
+
+// QR renders the link as a QR code image in the given format, see RenderQR.
+func (link *ClickToChat) QR(w io.Writer, format ImageFormat, opts *qrcode.Options) error {
+	value, err := link.URL()
+	if err != nil {
+		return err
+	}
+
+	return RenderQR(w, value, format, opts)
+}
+
+// NormalizePhoneNumber strips the formatting characters from number and checks that what is
+// left is an international phone number made of digits only.
+func NormalizePhoneNumber(number string) (string, error) {
+	normalized := strings.TrimPrefix(strings.TrimSpace(number), "+")
+	normalized = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		default:
+			return r
+		}
+	}, normalized)
+
+	if len(normalized) < minPhoneNumberDigits || len(normalized) > maxPhoneNumberDigits {
+		return "", fmt.Errorf("%w %q: must have between %d and %d digits",
+			ErrInvalidPhoneNumber, number, minPhoneNumberDigits, maxPhoneNumberDigits)
+	}
+
+	for _, r := range normalized {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w %q: must contain digits only", ErrInvalidPhoneNumber, number)
+		}
+	}
+
+	if normalized[0] == '0' {
+		return "", fmt.Errorf("%w %q: must start with the country code", ErrInvalidPhoneNumber, number)
+	}
+
+	return normalized, nil
+}
+
+// RenderQR renders link, for example the DeepLinkURL of a CreateResponse or a click to chat
+// link, as a QR code locally instead of using the image hosted by the API. SVG output is
+// vector based and suitable for print.
+func RenderQR(w io.Writer, link string, format ImageFormat, opts *qrcode.Options) error {
+	switch ImageFormat(strings.ToUpper(string(format))) {
+	case ImageFormatPNG:
+		return qrcode.PNG(w, []byte(link), opts) //nolint:wrapcheck
+	case ImageFormatSVG:
+		return qrcode.SVG(w, []byte(link), opts) //nolint:wrapcheck
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClickToChat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		number  string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "formatted number",
+			number: "+255 (700) 000-000",
+			want:   "https://wa.me/255700000000",
+		},
+		{
+			name:   "prefilled text",
+			number: "255700000000",
+			text:   "Habari yako? 50% & more",
+			want:   "https://wa.me/255700000000?text=Habari%20yako%3F%2050%25%20%26%20more",
+		},
+		{
+			name:    "leading zero",
+			number:  "0700000000",
+			wantErr: true,
+		},
+		{
+			name:    "letters",
+			number:  "2557000abc",
+			wantErr: true,
+		},
+		{
+			name:    "too short",
+			number:  "255",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewClickToChat(tt.number).Text(tt.text).URL()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPhoneNumber) {
+					t.Errorf("expected ErrInvalidPhoneNumber, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("URL() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderQR(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := NewClickToChat("255700000000").Text("Hi").QR(&buf, ImageFormatSVG, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Errorf("expected an svg document")
+	}
+
+	if err := RenderQR(&buf, "https://wa.me/255700000000", "GIF", nil); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
@@ -32,6 +32,10 @@ type (
 		AccessToken       string
 		PhoneNumberID     string
 		BusinessAccountID string
+
+		// AppSecret, when set, is used to sign every request with an appsecret_proof, which
+		// is required by apps that have "Require App Secret" turned on.
+		AppSecret string
 	}
 
 	// ConfigReader is an interface that can be used to read the configuration
@@ -0,0 +1,335 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables read by EnvConfigReader
+// when no prefix is given, for example WHATSAPP_ACCESS_TOKEN.
+const DefaultEnvPrefix = "WHATSAPP"
+
+// ConfigField names a field of Config in validation errors and source reports.
+type ConfigField string
+
+const (
+	ConfigFieldBaseURL           ConfigField = "BaseURL"
+	ConfigFieldVersion           ConfigField = "Version"
+	ConfigFieldAccessToken       ConfigField = "AccessToken"
+	ConfigFieldPhoneNumberID     ConfigField = "PhoneNumberID"
+	ConfigFieldBusinessAccountID ConfigField = "BusinessAccountID"
+	ConfigFieldAppSecret         ConfigField = "AppSecret"
+)
+
+var ErrMissingConfigField = errors.New("missing required config field")
+
+//nolint:gochecknoglobals
+var (
+	// MessagingConfigFields are the fields needed to send messages.
+	MessagingConfigFields = []ConfigField{ConfigFieldAccessToken, ConfigFieldPhoneNumberID}
+
+	// ManagementConfigFields are the fields needed by the business management calls such as
+	// ListPhoneNumbers and the templates API.
+	ManagementConfigFields = []ConfigField{
+		ConfigFieldAccessToken, ConfigFieldPhoneNumberID, ConfigFieldBusinessAccountID,
+	}
+)
+
+// configFields maps every ConfigField to its key, written in snake case, and its value.
+//
+//nolint:gochecknoglobals
+var configFields = []struct {
+	field ConfigField
+	key   string
+	value func(config *Config) *string
+}{
+	{ConfigFieldBaseURL, "base_url", func(c *Config) *string { return &c.BaseURL }},
+	{ConfigFieldVersion, "version", func(c *Config) *string { return &c.Version }},
+	{ConfigFieldAccessToken, "access_token", func(c *Config) *string { return &c.AccessToken }},
+	{ConfigFieldPhoneNumberID, "phone_number_id", func(c *Config) *string { return &c.PhoneNumberID }},
+	{ConfigFieldBusinessAccountID, "business_account_id", func(c *Config) *string { return &c.BusinessAccountID }},
+	{ConfigFieldAppSecret, "app_secret", func(c *Config) *string { return &c.AppSecret }},
+}
+
+// Validate checks that the given fields are set. It returns an error wrapping
+// ErrMissingConfigField that lists every missing field.
+func (config *Config) Validate(required ...ConfigField) error {
+	var missing []string
+	for _, field := range required {
+		for _, f := range configFields {
+			if f.field == field && *f.value(config) == "" {
+				missing = append(missing, string(field))
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingConfigField, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// EnvConfigReader reads the configuration from environment variables named after the
+// fields with a prefix: <PREFIX>_BASE_URL, <PREFIX>_VERSION, <PREFIX>_ACCESS_TOKEN,
+// <PREFIX>_PHONE_NUMBER_ID, <PREFIX>_BUSINESS_ACCOUNT_ID and <PREFIX>_APP_SECRET. Unset
+// variables leave the fields empty.
+type EnvConfigReader struct {
+	Prefix string
+	lookup func(key string) (string, bool)
+}
+
+// NewEnvConfigReader returns an EnvConfigReader for the prefix, DefaultEnvPrefix if empty.
+func NewEnvConfigReader(prefix string) *EnvConfigReader {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+
+	return &EnvConfigReader{Prefix: prefix, lookup: os.LookupEnv}
+}
+
+// Read implements the ConfigReader interface.
+func (reader *EnvConfigReader) Read(_ context.Context) (*Config, error) {
+	lookup := reader.lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	config := &Config{}
+	for _, f := range configFields {
+		if value, ok := lookup(reader.Prefix + "_" + strings.ToUpper(f.key)); ok {
+			*f.value(config) = value
+		}
+	}
+
+	return config, nil
+}
+
+// FileConfigReader reads the configuration from a file. Files starting with "{" are
+// decoded as JSON, anything else as flat YAML-like "key: value" lines where "#" starts a
+// comment and values may be quoted. Keys are the snake case field names, for example
+// access_token, other keys and indented lines are ignored.
+type FileConfigReader struct {
+	Path string
+
+	// Optional makes a missing file read as an empty configuration instead of an error.
+	Optional bool
+}
+
+// NewFileConfigReader returns a FileConfigReader for the file at path.
+func NewFileConfigReader(path string) *FileConfigReader {
+	return &FileConfigReader{Path: path}
+}
+
+// Read implements the ConfigReader interface.
+func (reader *FileConfigReader) Read(_ context.Context) (*Config, error) {
+	content, err := os.ReadFile(reader.Path)
+	if err != nil {
+		if reader.Optional && errors.Is(err, os.ErrNotExist) {
+			return &Config{}, nil
+		}
+
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	values := make(map[string]string)
+	if trimmed := bytes.TrimSpace(content); bytes.HasPrefix(trimmed, []byte("{")) {
+		var raw map[string]any
+		if err = json.Unmarshal(trimmed, &raw); err != nil {
+			return nil, fmt.Errorf("decode config file %s: %w", reader.Path, err)
+		}
+		for key, value := range raw {
+			if s, ok := value.(string); ok {
+				values[key] = s
+			}
+		}
+	} else if values, err = parseConfigLines(content); err != nil {
+		return nil, fmt.Errorf("decode config file %s: %w", reader.Path, err)
+	}
+
+	config := &Config{}
+	for _, f := range configFields {
+		*f.value(config) = values[f.key]
+	}
+
+	return config, nil
+}
+
+func parseConfigLines(content []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || text[0] == ' ' || text[0] == '\t' {
+			continue
+		}
+
+		if text = strings.TrimSpace(text); strings.HasPrefix(text, "#") || text == "---" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", line)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+
+		values[strings.TrimSpace(key)] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+
+	return values, nil
+}
+
+// FlagConfigReader reads the configuration from command line flags registered on a
+// flag.FlagSet. The flags are named after the fields in kebab case with an optional prefix,
+// for example -whatsapp-access-token. Only flags set on the command line are read, so that
+// flags can be chained with other readers.
+type FlagConfigReader struct {
+	flags  *flag.FlagSet
+	names  map[string]func(config *Config) *string
+	values map[string]*string
+}
+
+// NewFlagConfigReader registers the configuration flags on flags and returns a reader for
+// them. The reader must be used after flags.Parse has been called.
+func NewFlagConfigReader(flags *flag.FlagSet, prefix string) *FlagConfigReader {
+	reader := &FlagConfigReader{
+		flags:  flags,
+		names:  make(map[string]func(config *Config) *string),
+		values: make(map[string]*string),
+	}
+
+	for _, f := range configFields {
+		name := strings.ReplaceAll(f.key, "_", "-")
+		if prefix != "" {
+			name = prefix + "-" + name
+		}
+		reader.names[name] = f.value
+		reader.values[name] = flags.String(name, "", fmt.Sprintf("whatsapp %s", f.field))
+	}
+
+	return reader
+}
+
+// Read implements the ConfigReader interface.
+func (reader *FlagConfigReader) Read(_ context.Context) (*Config, error) {
+	config := &Config{}
+	reader.flags.Visit(func(f *flag.Flag) {
+		if value, ok := reader.names[f.Name]; ok {
+			*value(config) = *reader.values[f.Name]
+		}
+	})
+
+	return config, nil
+}
+
+// ConfigSource is a named ConfigReader used by ChainReader.
+type ConfigSource struct {
+	Name   string
+	Reader ConfigReader
+}
+
+// ChainReader merges the configuration read from several sources. Sources are listed from
+// the highest to the lowest precedence: every field takes the first non-empty value.
+//
+//	reader := whatsapp.NewChainReader(
+//		whatsapp.ConfigSource{Name: "flags", Reader: whatsapp.NewFlagConfigReader(flag.CommandLine, "")},
+//		whatsapp.ConfigSource{Name: "env", Reader: whatsapp.NewEnvConfigReader("")},
+//		whatsapp.ConfigSource{Name: "file", Reader: &whatsapp.FileConfigReader{Path: "whatsapp.yaml", Optional: true}},
+//	).Require(whatsapp.MessagingConfigFields...)
+type ChainReader struct {
+	sources  []ConfigSource
+	required []ConfigField
+}
+
+// NewChainReader returns a ChainReader for the sources, nil readers are skipped.
+func NewChainReader(sources ...ConfigSource) *ChainReader {
+	chain := &ChainReader{}
+	for _, source := range sources {
+		if source.Reader != nil {
+			chain.sources = append(chain.sources, source)
+		}
+	}
+
+	return chain
+}
+
+// Require sets the fields that must be set once all the sources are merged.
+func (chain *ChainReader) Require(fields ...ConfigField) *ChainReader {
+	chain.required = fields
+
+	return chain
+}
+
+// Read implements the ConfigReader interface.
+func (chain *ChainReader) Read(ctx context.Context) (*Config, error) {
+	config, _, err := chain.ReadWithSources(ctx)
+
+	return config, err
+}
+
+// ReadWithSources reads and merges the sources like Read, and also reports the name of the
+// source that supplied each field that is set.
+func (chain *ChainReader) ReadWithSources(ctx context.Context) (*Config, map[ConfigField]string, error) {
+	config := &Config{}
+	sources := make(map[ConfigField]string)
+
+	for _, source := range chain.sources {
+		partial, err := source.Reader.Read(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read config from %s: %w", source.Name, err)
+		}
+		if partial == nil {
+			continue
+		}
+
+		for _, f := range configFields {
+			if value := *f.value(partial); value != "" && *f.value(config) == "" {
+				*f.value(config) = value
+				sources[f.field] = source.Name
+			}
+		}
+	}
+
+	if err := config.Validate(chain.required...); err != nil {
+		return nil, sources, err
+	}
+
+	return config, sources, nil
+}
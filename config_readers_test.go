@@ -0,0 +1,113 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileConfigReader(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "json",
+			content: `{"access_token": "token", "phone_number_id": "1234", "other": 1}`,
+		},
+		{
+			name: "yaml",
+			content: "# whatsapp\naccess_token: \"token\"\nphone_number_id: 1234 # sandbox\n" +
+				"logging:\n  level: debug\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := NewFileConfigReader(path).Read(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if config.AccessToken != "token" || config.PhoneNumberID != "1234" {
+				t.Errorf("unexpected config %+v", config)
+			}
+		})
+	}
+}
+
+func TestChainReader(t *testing.T) {
+	t.Parallel()
+	env := NewEnvConfigReader("APP")
+	env.lookup = func(key string) (string, bool) {
+		value, ok := map[string]string{
+			"APP_ACCESS_TOKEN":    "env-token",
+			"APP_PHONE_NUMBER_ID": "env-phone",
+		}[key]
+
+		return value, ok
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagReader := NewFlagConfigReader(flags, "whatsapp")
+	if err := flags.Parse([]string{"-whatsapp-access-token", "flag-token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	file := &FileConfigReader{Path: filepath.Join(t.TempDir(), "missing.yaml"), Optional: true}
+	chain := NewChainReader(
+		ConfigSource{Name: "flags", Reader: flagReader},
+		ConfigSource{Name: "env", Reader: env},
+		ConfigSource{Name: "file", Reader: file},
+	)
+
+	config, sources, err := chain.ReadWithSources(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.AccessToken != "flag-token" || sources[ConfigFieldAccessToken] != "flags" {
+		t.Errorf("access token %q from %q, want flag-token from flags", config.AccessToken,
+			sources[ConfigFieldAccessToken])
+	}
+
+	if config.PhoneNumberID != "env-phone" || sources[ConfigFieldPhoneNumberID] != "env" {
+		t.Errorf("phone number id %q from %q, want env-phone from env", config.PhoneNumberID,
+			sources[ConfigFieldPhoneNumberID])
+	}
+
+	_, err = chain.Require(ManagementConfigFields...).Read(context.TODO())
+	if !errors.Is(err, ErrMissingConfigField) {
+		t.Errorf("expected ErrMissingConfigField, got %v", err)
+	}
+}
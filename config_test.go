@@ -0,0 +1,64 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+func TestClientAppSecretProof(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get(whttp.AppSecretProofParam) != whttp.AppSecretProof("secret", "token") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid appsecret_proof","code":100}}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithConfig(&Config{
+		BaseURL:           server.URL,
+		AccessToken:       "token",
+		PhoneNumberID:     "1234",
+		BusinessAccountID: "5678",
+		AppSecret:         "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bearer header authentication
+	if _, err = client.ListQRCodes(context.TODO(), nil); err != nil {
+		t.Errorf("ListQRCodes() error = %v", err)
+	}
+
+	// access_token query parameter authentication
+	if _, err = client.ListPhoneNumbers(context.TODO(), nil); err != nil {
+		t.Errorf("ListPhoneNumbers() error = %v", err)
+	}
+}
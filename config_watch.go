@@ -0,0 +1,205 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultConfigPollInterval is how often WatchConfig reads the config source.
+	DefaultConfigPollInterval = 5 * time.Minute
+
+	// DefaultConfigFilePollInterval is how often WatchConfig checks a watched file for changes.
+	DefaultConfigFilePollInterval = time.Second
+)
+
+type (
+	// OnConfigChangeFunc is called after the configuration of a Client has been replaced with
+	// the previous and the current configuration. It must not modify either of them.
+	OnConfigChangeFunc func(previous, current *Config)
+
+	// ConfigWatchOption configures WatchConfig.
+	ConfigWatchOption func(*configWatch)
+
+	configWatch struct {
+		interval time.Duration
+		file     string
+		onError  func(err error)
+	}
+)
+
+// WithConfigPollInterval sets how often the config source is read.
+func WithConfigPollInterval(interval time.Duration) ConfigWatchOption {
+	return func(watch *configWatch) {
+		watch.interval = interval
+	}
+}
+
+// WithConfigFile reads the config source only when the file at path changes, which is
+// checked every DefaultConfigFilePollInterval unless WithConfigPollInterval is also given.
+// The file is usually the one read by a FileConfigReader in the source.
+func WithConfigFile(path string) ConfigWatchOption {
+	return func(watch *configWatch) {
+		watch.file = path
+	}
+}
+
+// WithConfigWatchErrorHandler sets a function called with the errors met while reading the
+// config source. The current configuration is kept when the source fails.
+func WithConfigWatchErrorHandler(fn func(err error)) ConfigWatchOption {
+	return func(watch *configWatch) {
+		watch.onError = fn
+	}
+}
+
+// OnConfigChange registers a function called every time the configuration is replaced.
+func (client *Client) OnConfigChange(fn OnConfigChangeFunc) {
+	if fn == nil {
+		return
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.changeHooks = append(client.changeHooks, fn)
+}
+
+// Config returns a copy of the current configuration.
+func (client *Client) Config() Config {
+	return *client.config()
+}
+
+func (client *Client) config() *Config {
+	return client.configuration.Load()
+}
+
+// UpdateConfig replaces the configuration used by the following calls, calls in flight
+// keep the configuration they started with. Empty fields of config keep their current
+// values, so a source that only supplies a rotated AccessToken can be used as is.
+// The OnConfigChange functions are called when a field has changed.
+func (client *Client) UpdateConfig(config *Config) error {
+	if config == nil {
+		return ErrConfigNil
+	}
+
+	client.mu.Lock()
+	previous := client.config()
+	current := *previous
+	for _, f := range configFields {
+		if value := *f.value(config); value != "" {
+			*f.value(&current) = value
+		}
+	}
+
+	if current == *previous {
+		client.mu.Unlock()
+
+		return nil
+	}
+
+	client.configuration.Store(&current)
+	hooks := client.changeHooks
+	client.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(previous, &current)
+	}
+
+	return nil
+}
+
+// WatchConfig keeps the configuration of the client in sync with reader until ctx is done,
+// and then returns the context error. The reader is polled every DefaultConfigPollInterval,
+// or when a file changes if WithConfigFile is used. It is meant to run in its own goroutine:
+//
+//	go client.WatchConfig(ctx, whatsapp.NewFileConfigReader(path), whatsapp.WithConfigFile(path))
+func (client *Client) WatchConfig(ctx context.Context, reader ConfigReader, options ...ConfigWatchOption) error {
+	watch := &configWatch{}
+	for _, option := range options {
+		if option == nil {
+			continue
+		}
+		option(watch)
+	}
+
+	fileOnly := watch.file != ""
+	if watch.interval <= 0 {
+		watch.interval = DefaultConfigPollInterval
+		if fileOnly {
+			watch.interval = DefaultConfigFilePollInterval
+		}
+	}
+
+	var lastModified time.Time
+	var lastSize int64
+	if fileOnly {
+		if info, err := os.Stat(watch.file); err == nil {
+			lastModified, lastSize = info.ModTime(), info.Size()
+		}
+	}
+
+	ticker := time.NewTicker(watch.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
+		case <-ticker.C:
+		}
+
+		if fileOnly {
+			info, err := os.Stat(watch.file)
+			if err != nil {
+				watch.report(fmt.Errorf("watch config file: %w", err))
+
+				continue
+			}
+
+			if info.ModTime().Equal(lastModified) && info.Size() == lastSize {
+				continue
+			}
+			lastModified, lastSize = info.ModTime(), info.Size()
+		}
+
+		config, err := reader.Read(ctx)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				watch.report(fmt.Errorf("read config: %w", err))
+			}
+
+			continue
+		}
+
+		if err = client.UpdateConfig(config); err != nil {
+			watch.report(err)
+		}
+	}
+}
+
+func (watch *configWatch) report(err error) {
+	if watch.onError != nil {
+		watch.onError(err)
+	}
+}
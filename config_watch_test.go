@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientWatchConfig(t *testing.T) {
+	t.Parallel()
+	var lastToken atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastToken.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "whatsapp.yaml")
+	if err := os.WriteFile(path, []byte("access_token: first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "first", PhoneNumberID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan *Config, 1)
+	client.OnConfigChange(func(_, current *Config) {
+		changes <- current
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WatchConfig(ctx, NewFileConfigReader(path),
+			WithConfigFile(path), WithConfigPollInterval(5*time.Millisecond))
+	}()
+
+	// make sure the modification time changes on file systems with a coarse resolution
+	time.Sleep(20 * time.Millisecond)
+	if err = os.WriteFile(path, []byte("access_token: second\nversion: v17.0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	_ = os.Chtimes(path, later, later)
+
+	select {
+	case current := <-changes:
+		if current.AccessToken != "second" || current.Version != "v17.0" || current.PhoneNumberID != "1234" {
+			t.Errorf("unexpected config after change %+v", current)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change was not detected")
+	}
+
+	cancel()
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("WatchConfig() error = %v, want context.Canceled", err)
+	}
+
+	if _, err = client.DeleteQRCode(context.TODO(), "A"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lastToken.Load(); got != "Bearer second" {
+		t.Errorf("request used %v, want the rotated token", got)
+	}
+}
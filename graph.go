@@ -0,0 +1,338 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+// ErrGraphURLNotAllowed is returned when a GraphClient is given an absolute URL that is not on
+// the configured BaseURL, which would send the access token to another host.
+var ErrGraphURLNotAllowed = errors.New("url is not on the configured base url")
+
+type (
+	// GraphClient calls Graph API endpoints that do not have a typed method yet. The calls
+	// use the token, hooks, operation middleware and error decoding of the Client, so a
+	// failed call returns a *whttp.ResponseError wrapping a *werrors.Error like the typed
+	// methods do.
+	//
+	//	var out struct {
+	//		Data []struct {
+	//			ID   string `json:"id"`
+	//			Name string `json:"name"`
+	//		} `json:"data"`
+	//	}
+	//	err := client.Graph().Get(ctx, wabaID+"/message_templates", map[string]string{"limit": "10"}, &out)
+	GraphClient struct {
+		client *Client
+	}
+
+	// GraphBody sets the body of a request made with GraphClient.Post. Use JSONBody,
+	// FormBody or MultipartBody.
+	GraphBody func(request *whttp.Request) error
+
+	// MultipartFile is a file part of a MultipartBody.
+	MultipartFile struct {
+		Field       string
+		Filename    string
+		ContentType string
+		Content     io.Reader
+	}
+)
+
+// Graph returns a GraphClient that uses the configuration of the client.
+func (client *Client) Graph() *GraphClient {
+	return &GraphClient{client: client}
+}
+
+// Get sends a GET request to path and decodes the response into out. The path is relative
+// to the API version, for example "<WABA-ID>/message_templates", and its segments are
+// escaped. An absolute URL, such as the next link of a page, is used as is when its scheme
+// and host are the ones of Config.BaseURL, otherwise ErrGraphURLNotAllowed is returned.
+func (graph *GraphClient) Get(ctx context.Context, path string, query map[string]string, out any,
+	opts ...CallOption,
+) error {
+	return graph.Do(ctx, http.MethodGet, path, query, nil, out, opts...)
+}
+
+// Post sends a POST request with the given body, which may be nil, to path and decodes the
+// response into out.
+func (graph *GraphClient) Post(ctx context.Context, path string, query map[string]string, body GraphBody,
+	out any, opts ...CallOption,
+) error {
+	return graph.Do(ctx, http.MethodPost, path, query, body, out, opts...)
+}
+
+// Delete sends a DELETE request to path and decodes the response into out.
+func (graph *GraphClient) Delete(ctx context.Context, path string, query map[string]string, out any,
+	opts ...CallOption,
+) error {
+	return graph.Do(ctx, http.MethodDelete, path, query, nil, out, opts...)
+}
+
+// Do sends a request with any method to path and decodes the response into out. When out
+// is nil the response body is discarded, error responses are still returned.
+func (graph *GraphClient) Do(ctx context.Context, method, path string, query map[string]string, body GraphBody,
+	out any, opts ...CallOption,
+) error {
+	ctx = graph.client.withCallOptions(ctx, opts)
+	config := graph.client.config()
+	name := "graph " + strings.ToLower(method)
+
+	route, err := graphRoute(config.BaseURL, path)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", name, path, err)
+	}
+
+	request := &whttp.Request{
+		Context: &whttp.RequestContext{
+			TokenSource:       graph.client.tokens,
+			Name:              name,
+			BaseURL:           config.BaseURL,
+			ApiVersion:        config.Version,
+			PhoneNumberID:     config.PhoneNumberID,
+			BusinessAccountID: config.BusinessAccountID,
+			Route:             route,
+		},
+		Method: method,
+		Bearer: config.AccessToken,
+		Query:  query,
+	}
+
+	if body != nil {
+		if err := body(request); err != nil {
+			return fmt.Errorf("%s %s: %w", name, path, err)
+		}
+	}
+
+	// the response is decoded even when it is not wanted, otherwise error responses
+	// would go unnoticed
+	if out == nil {
+		out = &json.RawMessage{}
+	}
+
+	if err := graph.client.bc.do(ctx, request, out); err != nil {
+		return fmt.Errorf("%s %s: %w", name, path, err)
+	}
+
+	return nil
+}
+
+// Pages returns a GraphPageIterator over the data of the list endpoint at path.
+//
+//	pages := client.Graph().Pages(wabaID+"/phone_numbers", nil)
+//	for pages.Next(ctx) {
+//		var number whatsapp.PhoneNumber
+//		if err := pages.Decode(&number); err != nil {
+//			return err
+//		}
+//	}
+//	if err := pages.Err(); err != nil {
+//		return err
+//	}
+func (graph *GraphClient) Pages(path string, query map[string]string, opts ...CallOption) *GraphPageIterator {
+	return &GraphPageIterator{graph: graph, path: path, query: query, callOpts: opts}
+}
+
+// GraphPage is a page returned by a list endpoint.
+type GraphPage struct {
+	Data   []json.RawMessage `json:"data"`
+	Paging *Paging           `json:"paging,omitempty"`
+}
+
+// GraphPageIterator goes through the items of a list endpoint page by page, following the
+// next links returned by the API. It is not safe for concurrent use.
+type GraphPageIterator struct {
+	graph    *GraphClient
+	path     string
+	query    map[string]string
+	callOpts []CallOption
+	page     []json.RawMessage
+	current  json.RawMessage
+	done     bool
+	err      error
+}
+
+// Next advances to the next item, fetching the next page when the current one is
+// exhausted. It returns false when there are no more items or an error occurred.
+func (it *GraphPageIterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			it.current = nil
+
+			return false
+		}
+
+		it.fetch(ctx)
+	}
+
+	it.current, it.page = it.page[0], it.page[1:]
+
+	return true
+}
+
+func (it *GraphPageIterator) fetch(ctx context.Context) {
+	var page GraphPage
+	if err := it.graph.Get(ctx, it.path, it.query, &page, it.callOpts...); err != nil {
+		it.err = err
+
+		return
+	}
+
+	it.page = page.Data
+	if page.Paging == nil || page.Paging.Next == "" || len(page.Data) == 0 {
+		it.done = true
+
+		return
+	}
+
+	// the next link already holds the query and the cursor
+	it.path, it.query = page.Paging.Next, nil
+}
+
+// Item returns the raw JSON of the current item.
+func (it *GraphPageIterator) Item() json.RawMessage {
+	return it.current
+}
+
+// Decode decodes the current item into v.
+func (it *GraphPageIterator) Decode(v any) error {
+	if err := codec.OrDefault(it.graph.client.bc.codec).Unmarshal(it.current, v); err != nil {
+		return fmt.Errorf("decode graph item: %w", err)
+	}
+
+	return nil
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *GraphPageIterator) Err() error {
+	return it.err
+}
+
+// JSONBody sends v encoded as JSON.
+func JSONBody(v any) GraphBody {
+	return func(request *whttp.Request) error {
+		request.Payload = v
+		request.Headers = withHeader(request.Headers, "Content-Type", "application/json")
+
+		return nil
+	}
+}
+
+// FormBody sends the values as an application/x-www-form-urlencoded form.
+func FormBody(values map[string]string) GraphBody {
+	return func(request *whttp.Request) error {
+		request.Form = values
+
+		return nil
+	}
+}
+
+// MultipartBody sends the fields and files as a multipart/form-data body, for example to
+// upload a file to an endpoint that has no typed method.
+func MultipartBody(fields map[string]string, files ...*MultipartFile) GraphBody {
+	return func(request *whttp.Request) error {
+		var payload bytes.Buffer
+		writer := multipart.NewWriter(&payload)
+
+		for _, file := range files {
+			if file == nil {
+				continue
+			}
+
+			header := make(textproto.MIMEHeader)
+			header.Set("Content-Disposition",
+				fmt.Sprintf(`form-data; name=%q; filename=%q`, file.Field, file.Filename))
+			if file.ContentType != "" {
+				header.Set("Content-Type", file.ContentType)
+			}
+
+			part, err := writer.CreatePart(header)
+			if err != nil {
+				return fmt.Errorf("multipart body: %w", err)
+			}
+
+			if _, err = io.Copy(part, file.Content); err != nil {
+				return fmt.Errorf("multipart body: %w", err)
+			}
+		}
+
+		for key, value := range fields {
+			if err := writer.WriteField(key, value); err != nil {
+				return fmt.Errorf("multipart body: %w", err)
+			}
+		}
+
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("multipart body: %w", err)
+		}
+
+		request.Payload = payload.Bytes()
+		request.Headers = withHeader(request.Headers, "Content-Type", writer.FormDataContentType())
+
+		return nil
+	}
+}
+
+// graphRoute returns the route of a path given to the GraphClient. An absolute URL must have
+// the scheme and host of baseURL, so that the token is only sent to the Graph API.
+func graphRoute(baseURL, path string) (*whttp.Route, error) {
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		return whttp.RootRoute(strings.Trim(path, "/")), nil
+	}
+
+	target, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGraphURLNotAllowed, err)
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: base url: %w", ErrGraphURLNotAllowed, err)
+	}
+
+	if !strings.EqualFold(target.Scheme, base.Scheme) || !strings.EqualFold(target.Host, base.Host) {
+		return nil, fmt.Errorf("%w: %s://%s", ErrGraphURLNotAllowed, target.Scheme, target.Host)
+	}
+
+	return whttp.URLRoute(path), nil
+}
+
+func withHeader(headers map[string]string, key, value string) map[string]string {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers[key] = value
+
+	return headers
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+func TestGraphClient(t *testing.T) {
+	t.Parallel()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v16.0/5678/message_templates":
+			if r.URL.Query().Get("after") == "" {
+				_, _ = fmt.Fprintf(w, `{"data":[{"name":"a"},{"name":"b"}],"paging":{"next":"%s%s?after=c1"}}`,
+					server.URL, r.URL.Path)
+
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"data":[{"name":"c"}],"paging":{}}`)
+		case "POST /v16.0/5678/message_templates":
+			body, _ := io.ReadAll(r.Body)
+			_, _ = fmt.Fprintf(w, `{"id":%q}`, r.Header.Get("Content-Type")+" "+strings.TrimSpace(string(body)))
+		case "POST /v16.0/app/uploads":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+			file, _, _ := r.FormFile("file")
+			content, _ := io.ReadAll(file)
+			_, _ = fmt.Fprintf(w, `{"id":%q}`, r.FormValue("file_name")+":"+string(content))
+		case "DELETE /v16.0/5678/message_templates":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"error":{"message":"template not found","code":100}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.TODO()
+	graph := client.Graph()
+
+	var names []string
+	pages := graph.Pages("5678/message_templates", map[string]string{"limit": "2"})
+	for pages.Next(ctx) {
+		var item struct {
+			Name string `json:"name"`
+		}
+		if err = pages.Decode(&item); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, item.Name)
+	}
+
+	if err = pages.Err(); err != nil || fmt.Sprint(names) != "[a b c]" {
+		t.Errorf("pages = %v, %v, want [a b c]", names, err)
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body GraphBody
+		want string
+	}{
+		{
+			name: "json",
+			path: "5678/message_templates",
+			body: JSONBody(map[string]string{"name": "a"}),
+			want: `application/json {"name":"a"}`,
+		},
+		{
+			name: "form",
+			path: "/5678/message_templates/",
+			body: FormBody(map[string]string{"name": "a"}),
+			want: "application/x-www-form-urlencoded name=a",
+		},
+		{
+			name: "multipart",
+			path: "app/uploads",
+			body: MultipartBody(map[string]string{"file_name": "logo.png"},
+				&MultipartFile{Field: "file", Filename: "logo.png", Content: strings.NewReader("png")}),
+			want: "logo.png:png",
+		},
+	}
+
+	for _, tt := range tests {
+		if err = graph.Post(ctx, tt.path, nil, tt.body, &created); err != nil || created.ID != tt.want {
+			t.Errorf("%s: Post() = %q, %v, want %q", tt.name, created.ID, err, tt.want)
+		}
+	}
+
+	err = graph.Delete(ctx, "5678/message_templates", map[string]string{"name": "a"}, nil)
+	var responseErr *whttp.ResponseError
+	if !errors.As(err, &responseErr) || responseErr.Err.Code != 100 {
+		t.Errorf("Delete() error = %v, want a response error with code 100", err)
+	}
+}
+
+func TestGraphClientFormBody(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"id":%q}`, r.FormValue("name"))
+	}))
+	defer server.Close()
+
+	// without keep-alive a request with an empty body is not retried on a new connection
+	httpClient := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	base := NewBaseClient(WithBaseHTTPClient(whttp.NewClient(whttp.WithHTTPClient(httpClient))))
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"},
+		WithBaseClient(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		ID string `json:"id"`
+	}
+	body := FormBody(map[string]string{"name": "a"})
+	if err = client.Graph().Post(context.TODO(), "5678/message_templates", nil, body, &out); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+
+	if out.ID != "a" {
+		t.Errorf("id = %q, want a", out.ID)
+	}
+}
+
+func TestGraphClientForeignURL(t *testing.T) {
+	t.Parallel()
+	var called atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"https://example.com/v16.0/1234", "http://127.0.0.1:1/v16.0/1234"} {
+		err = client.Graph().Get(context.TODO(), path, nil, nil)
+		if !errors.Is(err, ErrGraphURLNotAllowed) {
+			t.Errorf("Get(%s) error = %v, want ErrGraphURLNotAllowed", path, err)
+		}
+	}
+
+	if err = client.Graph().Get(context.TODO(), server.URL+"/v16.0/1234", nil, nil); err != nil {
+		t.Errorf("Get() on the base url error = %v", err)
+	}
+
+	if called.Load() != 1 {
+		t.Errorf("expected only the request on the base url to be sent, got %d", called.Load())
+	}
+}
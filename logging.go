@@ -0,0 +1,96 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/redact"
+)
+
+// WithLogger makes the client log its calls to logger, see WithBaseClientLogger. It has no
+// effect on a BaseClient set with WithBaseClient, which is configured by its owner, nor on
+// the clients of a Registry, see WithRegistryLogger.
+func WithLogger(logger *slog.Logger, options ...redact.Option) ClientOption {
+	return func(client *Client) {
+		client.logger = logger
+		client.logOptions = options
+	}
+}
+
+// WithBaseClientLogger makes the base client log every operation with LogOperationMiddleware
+// and every http call with whttp.WithLogger. Access tokens, app secrets and signatures are
+// always redacted, the options can mask phone numbers and message bodies and set how much
+// of a body is logged. The logger is also set on a client passed with WithBaseHTTPClient.
+func WithBaseClientLogger(logger *slog.Logger, options ...redact.Option) BaseClientOption {
+	return func(client *BaseClient) {
+		client.logger = logger
+		client.logOptions = options
+	}
+}
+
+// LogOperationMiddleware returns an OperationMiddleware that logs every operation with its
+// duration, at the debug level when it succeeds and at the warning level when it fails. A
+// failure with a whttp.ResponseError is logged with the details Meta support asks for.
+func LogOperationMiddleware(logger *slog.Logger) OperationMiddleware {
+	return func(next OperationHandler) OperationHandler {
+		return OperationHandlerFunc(func(ctx context.Context, operation *Operation) error {
+			start := time.Now()
+			err := next.Handle(ctx, operation)
+
+			level := slog.LevelDebug
+			if err != nil {
+				level = slog.LevelWarn
+			}
+
+			if !logger.Enabled(ctx, level) {
+				return err
+			}
+
+			attrs := []slog.Attr{
+				slog.String("operation", operation.Name),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			var responseErr *whttp.ResponseError
+			switch {
+			case errors.As(err, &responseErr):
+				attrs = append(attrs, slog.Any("error", responseErr))
+			case err != nil:
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+
+			logger.LogAttrs(ctx, level, "whatsapp operation", attrs...)
+
+			return err
+		})
+	}
+}
+
+// setLogger sets the logger of the http client and logs the operations, outermost so that
+// the duration covers the other middleware.
+func (c *BaseClient) setLogger(logger *slog.Logger, options ...redact.Option) {
+	c.base.SetLogger(logger, options...)
+	c.opmw = append([]OperationMiddleware{LogOperationMiddleware(logger)}, c.opmw...)
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientLogger(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v16.0/1234/message_qrdls" {
+			_, _ = w.Write([]byte(`{"data":[]}`))
+
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"message":"unknown path","code":803}}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewClientWithConfig(&Config{
+		BaseURL:       server.URL,
+		AccessToken:   "EAAG",
+		PhoneNumberID: "1234",
+		AppSecret:     "secret",
+	}, WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.ListQRCodes(context.TODO(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.GetMediaInformation(context.TODO(), "missing"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	logged := buf.String()
+	for _, want := range []string{
+		`msg="whatsapp operation" operation="list qr codes"`,
+		"level=WARN", "code=803",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log %s does not contain %s", logged, want)
+		}
+	}
+
+	if strings.Contains(logged, "EAAG") {
+		t.Errorf("access token logged: %s", logged)
+	}
+
+	if n := strings.Count(logged, "level=WARN"); n != 1 {
+		t.Errorf("failed call logged at the warning level %d times: %s", n, logged)
+	}
+}
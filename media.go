@@ -73,24 +73,30 @@ type (
 )
 
 // GetMediaInformation retrieve the media object by using its corresponding media ID.
-func (client *Client) GetMediaInformation(ctx context.Context, mediaID string) (*MediaInformation, error) {
+func (client *Client) GetMediaInformation(ctx context.Context, mediaID string,
+	opts ...CallOption,
+) (*MediaInformation, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	reqCtx := &whttp.RequestContext{
-		Name:       "get media",
-		BaseURL:    client.config.BaseURL,
-		ApiVersion: client.config.Version,
-		Endpoints:  []string{mediaID},
+		TokenSource:       client.tokens,
+		Name:              "get media",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.MediaRoute(mediaID),
 	}
 
 	params := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodGet,
-		Bearer:  client.config.AccessToken,
+		Bearer:  config.AccessToken,
 		Payload: nil,
 	}
 
 	var media MediaInformation
 
-	err := client.bc.base.Do(ctx, params, &media)
+	err := client.bc.do(ctx, params, &media)
 	if err != nil {
 		return nil, fmt.Errorf("get media: %w", err)
 	}
@@ -99,24 +105,30 @@ func (client *Client) GetMediaInformation(ctx context.Context, mediaID string) (
 }
 
 // DeleteMedia delete the media by using its corresponding media ID.
-func (client *Client) DeleteMedia(ctx context.Context, mediaID string) (*DeleteMediaResponse, error) {
+func (client *Client) DeleteMedia(ctx context.Context, mediaID string,
+	opts ...CallOption,
+) (*DeleteMediaResponse, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	reqCtx := &whttp.RequestContext{
-		Name:       "delete media",
-		BaseURL:    client.config.BaseURL,
-		ApiVersion: client.config.Version,
-		Endpoints:  []string{mediaID},
+		TokenSource:       client.tokens,
+		Name:              "delete media",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.MediaRoute(mediaID),
 	}
 
 	params := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodDelete,
 		Headers: map[string]string{"Content-Type": "application/json"},
-		Bearer:  client.config.AccessToken,
+		Bearer:  config.AccessToken,
 		Payload: nil,
 	}
 
 	resp := new(DeleteMediaResponse)
-	err := client.bc.base.Do(ctx, params, &resp)
+	err := client.bc.do(ctx, params, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("delete media: %w", err)
 	}
@@ -125,30 +137,34 @@ func (client *Client) DeleteMedia(ctx context.Context, mediaID string) (*DeleteM
 }
 
 func (client *Client) UploadMedia(ctx context.Context, mediaType MediaType, filename string,
-	fr io.Reader,
+	fr io.Reader, opts ...CallOption,
 ) (*UploadMediaResponse, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	payload, contentType, err := uploadMediaPayload(mediaType, filename, fr)
 	if err != nil {
 		return nil, err
 	}
 
 	reqCtx := &whttp.RequestContext{
-		Name:       "upload media",
-		BaseURL:    client.config.BaseURL,
-		ApiVersion: client.config.Version,
-		Endpoints:  []string{client.config.PhoneNumberID, "media"},
+		TokenSource:       client.tokens,
+		Name:              "upload media",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, "media"),
 	}
 
 	params := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodPost,
 		Headers: map[string]string{"Content-Type": contentType},
-		Bearer:  client.config.AccessToken,
+		Bearer:  config.AccessToken,
 		Payload: payload,
 	}
 
 	resp := new(UploadMediaResponse)
-	err = client.bc.base.Do(ctx, params, &resp)
+	err = client.bc.do(ctx, params, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("upload media: %w", err)
 	}
@@ -170,8 +186,13 @@ type DownloadResponseDecoder struct {
 }
 
 func (d *DownloadResponseDecoder) Decode(response *http.Response) error {
+	if d.Resp == nil {
+		d.Resp = &DownloadMediaResponse{}
+	}
+	d.response = response
 	d.Resp.Headers = response.Header
 	d.Resp.Body = response.Body
+	d.Resp.StatusCode = response.StatusCode
 
 	return nil
 }
@@ -185,7 +206,10 @@ func (d *DownloadResponseDecoder) Decode(response *http.Response) error {
 // If media fails to download, Facebook returns a 404 http status code. It is recommended to try to retrieve
 // a new media URL and download it again. This will go on for an n retries. If doing so doesn't resolve the issue,
 // please try to renew the access token, then retry downloading the media.
-func (client *Client) DownloadMedia(ctx context.Context, mediaID string, retries int) (*DownloadMediaResponse, error) {
+func (client *Client) DownloadMedia(ctx context.Context, mediaID string, retries int,
+	opts ...CallOption,
+) (*DownloadMediaResponse, error) {
+	ctx = client.withCallOptions(ctx, opts)
 	// create a for loop to retry the download if it fails with a 404 http status code.
 	for i := 0; i <= retries; i++ {
 		select {
@@ -198,61 +222,70 @@ func (client *Client) DownloadMedia(ctx context.Context, mediaID string, retries
 			return nil, err
 		}
 
-		request := whttp.MakeRequest(
-			whttp.WithRequestContext(&whttp.RequestContext{
-				Name:              "download media",
-				BaseURL:           media.URL,
-				ApiVersion:        client.config.Version,
-				PhoneNumberID:     client.config.PhoneNumberID,
-				Bearer:            client.config.AccessToken,
-				BusinessAccountID: "",
-				Endpoints:         nil,
-			}),
-			whttp.WithRequestName("download media"),
-			whttp.WithMethod(http.MethodGet),
-			whttp.WithBearer(client.config.AccessToken))
-		decoder := &DownloadResponseDecoder{}
-		if err := client.bc.base.DoWithDecoder(
-			ctx,
-			request,
-			whttp.RawResponseDecoder(decoder.Decode),
-			nil); err != nil {
+		// the body is streamed, so it is read before fetchMedia returns
+		var (
+			buf        bytes.Buffer
+			headers    http.Header
+			statusCode int
+		)
+		if err = client.fetchMedia(ctx, media.URL, func(response *http.Response) error {
+			headers, statusCode = response.Header, response.StatusCode
+			if statusCode != http.StatusOK {
+				return nil
+			}
+
+			if _, err := io.CopyN(&buf, response.Body, MaxDocSize); err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("read body: %w", err)
+			}
+
+			return nil
+		}); err != nil {
 			return nil, fmt.Errorf("media download: %w", err)
 		}
 
 		// retry
-		resp := decoder.response
-		if resp.StatusCode == http.StatusNotFound {
-			_ = resp.Body.Close()
+		if statusCode == http.StatusNotFound {
+			client.bc.base.Report(ctx, &whttp.Event{
+				Kind:        whttp.EventRetry,
+				RequestName: "download media",
+				Err:         fmt.Errorf("%w: %s: status %d", ErrMediaDownload, mediaID, statusCode),
+				Attempt:     i + 1,
+			})
 
 			continue
 		}
 
-		if resp.StatusCode != http.StatusOK {
-			_ = resp.Body.Close()
-
-			return nil, fmt.Errorf("%w: status %d", ErrMediaDownload, resp.StatusCode)
-		}
-
-		var buf bytes.Buffer
-		_, err = io.CopyN(&buf, resp.Body, MaxDocSize)
-		if err != nil && !errors.Is(err, io.EOF) {
-			_ = resp.Body.Close()
-
-			return nil, fmt.Errorf("media download: %w", err)
+		if statusCode != http.StatusOK {
+			return nil, fmt.Errorf("%w: status %d", ErrMediaDownload, statusCode)
 		}
 
-		_ = resp.Body.Close()
-
 		return &DownloadMediaResponse{
-			Headers: resp.Header,
-			Body:    &buf,
+			Headers:    headers,
+			Body:       &buf,
+			StatusCode: statusCode,
 		}, nil
 	}
 
 	return nil, fmt.Errorf("%w: retries exceeded", ErrMediaDownload)
 }
 
+// fetchMedia sends an authenticated GET request to a media URL returned by GetMediaInformation
+// and passes the response to decode. The media URL is used as is, it is not a Graph API path.
+func (client *Client) fetchMedia(ctx context.Context, mediaURL string, decode func(*http.Response) error) error {
+	config := client.config()
+	request := &whttp.Request{
+		Context: &whttp.RequestContext{
+			TokenSource: client.tokens,
+			Name:        "download media",
+			Route:       whttp.URLRoute(mediaURL),
+		},
+		Method: http.MethodGet,
+		Bearer: config.AccessToken,
+	}
+
+	return client.bc.doWithDecoder(ctx, request, whttp.RawResponseDecoder(decode), nil)
+}
+
 // uploadMediaPayload creates upload media request payload.
 // If nor error, payload content and request content type is returned.
 func uploadMediaPayload(mediaType MediaType, filename string, fr io.Reader) ([]byte, string, error) {
@@ -0,0 +1,365 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
+	werrors "github.com/piusalfred/whatsapp/pkg/errors"
+)
+
+// DefaultMediaRefreshWindow is how long before UploadedMediaTTL elapses a cached media ID
+// is considered stale and the content is uploaded again.
+const DefaultMediaRefreshWindow = 24 * time.Hour
+
+// ErrMediaCacheMiss is returned by a MediaCacheStore when there is no entry for a key.
+var ErrMediaCacheMiss = errors.New("media cache miss")
+
+type (
+	// CachedMedia is a media ID returned by UploadMedia together with the time it was
+	// uploaded and the time it is expected to expire.
+	CachedMedia struct {
+		MediaID    string    `json:"media_id"`
+		Type       MediaType `json:"type"`
+		Sha256     string    `json:"sha256"`
+		UploadedAt time.Time `json:"uploaded_at"`
+		ExpiresAt  time.Time `json:"expires_at"`
+	}
+
+	// MediaCacheStore persists CachedMedia entries. Get must return ErrMediaCacheMiss
+	// when there is no entry for the key.
+	MediaCacheStore interface {
+		Get(ctx context.Context, key string) (*CachedMedia, error)
+		Put(ctx context.Context, key string, media *CachedMedia) error
+		Delete(ctx context.Context, key string) error
+	}
+
+	// MediaCache wraps Client.UploadMedia and reuses media IDs for content that has
+	// already been uploaded. Entries are keyed by the SHA-256 of the content and the
+	// MediaType, see MediaCacheKey.
+	//
+	// A cached media ID is reused until it is within the refresh window of its expiry,
+	// after which the content is uploaded again. If a send fails because WhatsApp no
+	// longer recognises the media ID, the entry is dropped and the content re-uploaded.
+	MediaCache struct {
+		client        *Client
+		store         MediaCacheStore
+		refreshWindow time.Duration
+		now           func() time.Time
+	}
+
+	MediaCacheOption func(*MediaCache)
+)
+
+// WithMediaRefreshWindow sets how long before expiry a cached media ID is refreshed.
+func WithMediaRefreshWindow(window time.Duration) MediaCacheOption {
+	return func(cache *MediaCache) {
+		cache.refreshWindow = window
+	}
+}
+
+// WithMediaCacheClock sets the function used to get the current time.
+func WithMediaCacheClock(now func() time.Time) MediaCacheOption {
+	return func(cache *MediaCache) {
+		cache.now = now
+	}
+}
+
+// NewMediaCache creates a MediaCache that uploads through client and keeps media IDs
+// in store. If store is nil a MemoryMediaCacheStore is used.
+func NewMediaCache(client *Client, store MediaCacheStore, options ...MediaCacheOption) *MediaCache {
+	if store == nil {
+		store = NewMemoryMediaCacheStore()
+	}
+	cache := &MediaCache{
+		client:        client,
+		store:         store,
+		refreshWindow: DefaultMediaRefreshWindow,
+		now:           time.Now,
+	}
+
+	for _, option := range options {
+		if option == nil {
+			continue
+		}
+		option(cache)
+	}
+
+	return cache
+}
+
+// MediaCacheKey returns the cache key for content of the given media type.
+func MediaCacheKey(mediaType MediaType, sum string) string {
+	return string(mediaType) + ":" + sum
+}
+
+// Upload returns a media ID for the content, uploading it only if there is no cached
+// media ID for it or the cached one is about to expire.
+func (cache *MediaCache) Upload(ctx context.Context, mediaType MediaType, filename string,
+	content io.Reader,
+) (*CachedMedia, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("media cache: read content: %w", err)
+	}
+
+	return cache.upload(ctx, mediaType, filename, data, false)
+}
+
+func (cache *MediaCache) upload(ctx context.Context, mediaType MediaType, filename string,
+	data []byte, force bool,
+) (*CachedMedia, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := MediaCacheKey(mediaType, hash)
+
+	if !force {
+		cached, err := cache.store.Get(ctx, key)
+		if err != nil && !errors.Is(err, ErrMediaCacheMiss) {
+			return nil, fmt.Errorf("media cache: get %s: %w", key, err)
+		}
+
+		if cached != nil && cache.now().Add(cache.refreshWindow).Before(cached.ExpiresAt) {
+			return cached, nil
+		}
+	}
+
+	resp, err := cache.client.UploadMedia(ctx, mediaType, filename, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("media cache: %w", err)
+	}
+
+	now := cache.now()
+	media := &CachedMedia{
+		MediaID:    resp.ID,
+		Type:       mediaType,
+		Sha256:     hash,
+		UploadedAt: now,
+		ExpiresAt:  now.Add(UploadedMediaTTL),
+	}
+
+	if err = cache.store.Put(ctx, key, media); err != nil {
+		return nil, fmt.Errorf("media cache: put %s: %w", key, err)
+	}
+
+	return media, nil
+}
+
+// SendMedia uploads the content through the cache and sends it to the recipient using
+// the resulting media ID. The MediaID and MediaLink of the given message are ignored.
+//
+// If WhatsApp rejects the media ID, the cache entry is dropped, the content uploaded
+// again and the message resent once.
+func (cache *MediaCache) SendMedia(ctx context.Context, recipient string, message *MediaMessage,
+	content io.Reader, cacheOptions *CacheOptions,
+) (*ResponseMessage, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("media cache: read content: %w", err)
+	}
+
+	media, err := cache.upload(ctx, message.Type, message.Filename, data, false)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := *message
+	msg.MediaID = media.MediaID
+	msg.MediaLink = ""
+
+	resp, err := cache.client.SendMedia(ctx, recipient, &msg, cacheOptions)
+	if err == nil || !IsInvalidMediaError(err) {
+		return resp, err
+	}
+
+	if media, err = cache.upload(ctx, message.Type, message.Filename, data, true); err != nil {
+		return nil, err
+	}
+	msg.MediaID = media.MediaID
+
+	return cache.client.SendMedia(ctx, recipient, &msg, cacheOptions)
+}
+
+// Invalidate removes the cached media ID for the content.
+func (cache *MediaCache) Invalidate(ctx context.Context, mediaType MediaType, content io.Reader) error {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return fmt.Errorf("media cache: read content: %w", err)
+	}
+
+	key := MediaCacheKey(mediaType, hex.EncodeToString(hash.Sum(nil)))
+	if err := cache.store.Delete(ctx, key); err != nil {
+		return fmt.Errorf("media cache: delete %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// IsInvalidMediaError reports whether err is a WhatsApp error saying the media sent
+// could not be used, for example because the media ID has expired. It is werrors.IsMediaError.
+func IsInvalidMediaError(err error) bool {
+	return werrors.IsMediaError(err)
+}
+
+var (
+	_ MediaCacheStore = (*MemoryMediaCacheStore)(nil)
+	_ MediaCacheStore = (*FileMediaCacheStore)(nil)
+)
+
+// MemoryMediaCacheStore is a MediaCacheStore that keeps entries in memory.
+type MemoryMediaCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string]*CachedMedia
+}
+
+// NewMemoryMediaCacheStore creates an empty MemoryMediaCacheStore.
+func NewMemoryMediaCacheStore() *MemoryMediaCacheStore {
+	return &MemoryMediaCacheStore{entries: make(map[string]*CachedMedia)}
+}
+
+func (store *MemoryMediaCacheStore) Get(_ context.Context, key string) (*CachedMedia, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	media, ok := store.entries[key]
+	if !ok {
+		return nil, ErrMediaCacheMiss
+	}
+
+	return media, nil
+}
+
+func (store *MemoryMediaCacheStore) Put(_ context.Context, key string, media *CachedMedia) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.entries[key] = media
+
+	return nil
+}
+
+func (store *MemoryMediaCacheStore) Delete(_ context.Context, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.entries, key)
+
+	return nil
+}
+
+type (
+	// FileMediaCacheStore is a MediaCacheStore that keeps entries in memory and writes them
+	// to a JSON file on every change, so they survive restarts.
+	FileMediaCacheStore struct {
+		path  string
+		cache *MemoryMediaCacheStore
+		codec codec.Codec
+	}
+
+	// FileMediaCacheStoreOption configures a FileMediaCacheStore.
+	FileMediaCacheStoreOption func(*FileMediaCacheStore)
+)
+
+// WithFileMediaCacheCodec sets the codec that encodes and decodes the file of the store,
+// usually the one set with WithBaseClientCodec.
+func WithFileMediaCacheCodec(c codec.Codec) FileMediaCacheStoreOption {
+	return func(store *FileMediaCacheStore) {
+		store.codec = c
+	}
+}
+
+// NewFileMediaCacheStore creates a FileMediaCacheStore backed by the file at path,
+// loading any entries already stored in it.
+func NewFileMediaCacheStore(path string, options ...FileMediaCacheStoreOption) (*FileMediaCacheStore, error) {
+	store := &FileMediaCacheStore{
+		path:  path,
+		cache: NewMemoryMediaCacheStore(),
+	}
+	for _, option := range options {
+		if option != nil {
+			option(store)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file media cache store: %w", err)
+	}
+
+	if len(data) > 0 {
+		if err = codec.OrDefault(store.codec).Unmarshal(data, &store.cache.entries); err != nil {
+			return nil, fmt.Errorf("file media cache store: decode %s: %w", path, err)
+		}
+	}
+
+	return store, nil
+}
+
+func (store *FileMediaCacheStore) Get(ctx context.Context, key string) (*CachedMedia, error) {
+	return store.cache.Get(ctx, key)
+}
+
+func (store *FileMediaCacheStore) Put(_ context.Context, key string, media *CachedMedia) error {
+	store.cache.mu.Lock()
+	defer store.cache.mu.Unlock()
+	store.cache.entries[key] = media
+
+	return store.flush()
+}
+
+func (store *FileMediaCacheStore) Delete(_ context.Context, key string) error {
+	store.cache.mu.Lock()
+	defer store.cache.mu.Unlock()
+	delete(store.cache.entries, key)
+
+	return store.flush()
+}
+
+// flush writes all entries to a temporary file and renames it over the store file.
+// The caller must hold the write lock.
+func (store *FileMediaCacheStore) flush() error {
+	data, err := codec.OrDefault(store.codec).Marshal(store.cache.entries)
+	if err != nil {
+		return fmt.Errorf("file media cache store: encode: %w", err)
+	}
+
+	tmp := store.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil { //nolint:gomnd
+		return fmt.Errorf("file media cache store: write: %w", err)
+	}
+
+	if err = os.Rename(tmp, filepath.Clean(store.path)); err != nil {
+		return fmt.Errorf("file media cache store: rename: %w", err)
+	}
+
+	return nil
+}
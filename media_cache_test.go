@@ -0,0 +1,171 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
+)
+
+func mediaCacheTestServer(t *testing.T, uploads, sends *int32, rejectFirstSend bool) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/media"):
+			n := atomic.AddInt32(uploads, 1)
+			_, _ = fmt.Fprintf(w, `{"id":"media-%d"}`, n)
+		case strings.HasSuffix(r.URL.Path, "/messages"):
+			n := atomic.AddInt32(sends, 1)
+			if rejectFirstSend && n == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"message":"media upload error","code":131053}}`))
+
+				return
+			}
+			_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.1"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestMediaCacheUpload(t *testing.T) {
+	t.Parallel()
+	var uploads, sends int32
+	server := mediaCacheTestServer(t, &uploads, &sends, false)
+	defer server.Close()
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, PhoneNumberID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	cache := NewMediaCache(client, nil, WithMediaCacheClock(func() time.Time { return now }))
+	ctx := context.TODO()
+
+	first, err := cache.Upload(ctx, MediaTypeDocument, "brochure.pdf", strings.NewReader("brochure"))
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	second, err := cache.Upload(ctx, MediaTypeDocument, "brochure.pdf", strings.NewReader("brochure"))
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if first.MediaID != second.MediaID || atomic.LoadInt32(&uploads) != 1 {
+		t.Errorf("expected cached media id, got %s and %s after %d uploads", first.MediaID, second.MediaID, uploads)
+	}
+
+	// same content but a different media type is a different entry
+	if _, err = cache.Upload(ctx, MediaTypeImage, "brochure.pdf", strings.NewReader("brochure")); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if atomic.LoadInt32(&uploads) != 2 { //nolint:gomnd
+		t.Errorf("expected 2 uploads, got %d", uploads)
+	}
+
+	// within the refresh window the content is uploaded again
+	now = now.Add(UploadedMediaTTL - time.Hour)
+	third, err := cache.Upload(ctx, MediaTypeDocument, "brochure.pdf", strings.NewReader("brochure"))
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	if third.MediaID == first.MediaID {
+		t.Errorf("expected media id to be refreshed, got %s", third.MediaID)
+	}
+}
+
+func TestMediaCacheSendMediaReuploadsInvalidMedia(t *testing.T) {
+	t.Parallel()
+	var uploads, sends int32
+	server := mediaCacheTestServer(t, &uploads, &sends, true)
+	defer server.Close()
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, PhoneNumberID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileMediaCacheStore(filepath.Join(t.TempDir(), "media.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewMediaCache(client, store)
+	message := &MediaMessage{Type: MediaTypeImage, Filename: "logo.png"}
+
+	resp, err := cache.SendMedia(context.TODO(), "255700000000", message, strings.NewReader("logo"), nil)
+	if err != nil {
+		t.Fatalf("SendMedia() error = %v", err)
+	}
+
+	gotUploads, gotSends := atomic.LoadInt32(&uploads), atomic.LoadInt32(&sends)
+	if resp.Messages[0].ID != "wamid.1" || gotUploads != 2 || gotSends != 2 { //nolint:gomnd
+		t.Errorf("expected a re-upload and resend, got %d uploads and %d sends", gotUploads, gotSends)
+	}
+
+	reloaded, err := NewFileMediaCacheStore(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reloaded.cache.entries) != 1 {
+		t.Fatalf("expected 1 persisted entry, got %d", len(reloaded.cache.entries))
+	}
+
+	for _, media := range reloaded.cache.entries {
+		if media.MediaID != "media-2" {
+			t.Errorf("expected persisted media id media-2, got %s", media.MediaID)
+		}
+	}
+}
+
+func TestFileMediaCacheStoreCodec(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "media.json")
+	if err := os.WriteFile(path, []byte(`{"image:abc":{"media_id":"media-1","unknown":true}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileMediaCacheStore(path); err != nil {
+		t.Errorf("NewFileMediaCacheStore() error = %v", err)
+	}
+
+	_, err := NewFileMediaCacheStore(path, WithFileMediaCacheCodec(codec.Strict()))
+	if !errors.Is(err, codec.ErrUnknownField) {
+		t.Errorf("NewFileMediaCacheStore() error = %v, want codec.ErrUnknownField", err)
+	}
+}
@@ -0,0 +1,427 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/models"
+	"github.com/piusalfred/whatsapp/webhooks"
+)
+
+const (
+	DefaultMediaPipelineWorkers    = 4
+	DefaultMediaPipelineQueueSize  = 100
+	DefaultMediaPipelineRetries    = 3
+	DefaultMediaPipelineRetryDelay = time.Second
+)
+
+var (
+	ErrMediaPipelineQueueFull = errors.New("media pipeline queue is full")
+	ErrMediaPipelineClosed    = errors.New("media pipeline is closed")
+	ErrMediaPathNotLocal      = errors.New("media path is not below the store directory")
+)
+
+type (
+	// MediaStore is where the MediaPipeline writes downloaded media. Save writes the content
+	// under the given path and returns the location it was stored at.
+	MediaStore interface {
+		Save(ctx context.Context, path, mimeType string, content io.Reader) (string, error)
+	}
+
+	// LocalMediaStore is a MediaStore that writes media to files under Dir.
+	LocalMediaStore struct {
+		Dir string
+	}
+
+	// MediaDownloadRequest describes an inbound media to download.
+	MediaDownloadRequest struct {
+		MediaID       string
+		MessageID     string
+		MessageType   string
+		From          string
+		PhoneNumberID string
+		MimeType      string
+		Filename      string
+	}
+
+	// DownloadedMedia describes a media that has been downloaded and stored by the MediaPipeline.
+	// Location is the value returned by MediaStore.Save and Sha256 is the hex encoded SHA-256 of
+	// the stored content.
+	DownloadedMedia struct {
+		Request  *MediaDownloadRequest
+		Location string
+		MimeType string
+		Sha256   string
+		Size     int64
+	}
+
+	// MediaDownloadCallback is called once for every MediaDownloadRequest, after the media has
+	// been stored or after all retries have failed. Exactly one of media and err is nil.
+	MediaDownloadCallback func(ctx context.Context, request *MediaDownloadRequest, media *DownloadedMedia, err error)
+
+	// MediaPathFunc returns the path under which the media is stored.
+	MediaPathFunc func(request *MediaDownloadRequest, mimeType string) string
+
+	// MediaPipeline downloads inbound media in the background. Use Hook to get a
+	// webhooks.OnMediaMessageHook that queues every media message received, the hook returns
+	// as soon as the media is queued so the webhook response is not delayed.
+	//
+	// A fixed number of workers resolve each media ID with Client.GetMediaInformation, download
+	// the content and write it to the MediaStore. Since media URLs expire after
+	// MediaDownloadLinkTTL, every attempt resolves a fresh URL and is bounded by that TTL.
+	MediaPipeline struct {
+		client     *Client
+		store      MediaStore
+		path       MediaPathFunc
+		callback   MediaDownloadCallback
+		workers    int
+		queueSize  int
+		retries    int
+		retryDelay time.Duration
+
+		queue     chan *mediaJob
+		wg        sync.WaitGroup
+		mu        sync.RWMutex
+		closed    bool
+		closeOnce sync.Once
+	}
+
+	MediaPipelineOption func(*MediaPipeline)
+
+	mediaJob struct {
+		ctx     context.Context //nolint:containedctx
+		request *MediaDownloadRequest
+	}
+)
+
+// Save implements MediaStore. A path that would leave Dir, for example one with "..", is
+// refused with ErrMediaPathNotLocal.
+func (store *LocalMediaStore) Save(_ context.Context, path, _ string, content io.Reader) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("local media store: %w: %q", ErrMediaPathNotLocal, path)
+	}
+
+	location := filepath.Join(store.Dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(location), 0o750); err != nil { //nolint:gomnd
+		return "", fmt.Errorf("local media store: %w", err)
+	}
+
+	file, err := os.Create(location)
+	if err != nil {
+		return "", fmt.Errorf("local media store: %w", err)
+	}
+
+	if _, err = io.Copy(file, content); err != nil {
+		_ = file.Close()
+
+		return "", fmt.Errorf("local media store: write %s: %w", location, err)
+	}
+
+	if err = file.Close(); err != nil {
+		return "", fmt.Errorf("local media store: close %s: %w", location, err)
+	}
+
+	return location, nil
+}
+
+// DefaultMediaPath stores media as <phone number id>/<message type>/<media id><extension>.
+// The parts come from the webhook payload, so each one is made a single file name: path
+// separators are replaced and ".." becomes "_".
+func DefaultMediaPath(request *MediaDownloadRequest, mimeType string) string {
+	var ext string
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		ext = exts[0]
+	} else if request.Filename != "" {
+		ext = filepath.Ext(request.Filename)
+	}
+
+	phoneID := request.PhoneNumberID
+	if phoneID == "" {
+		phoneID = "unknown"
+	}
+
+	return path.Join(mediaPathPart(phoneID), mediaPathPart(request.MessageType),
+		mediaPathPart(request.MediaID+ext))
+}
+
+// mediaPathPart returns part as a single local file name.
+func mediaPathPart(part string) string {
+	part = strings.NewReplacer("/", "_", `\`, "_").Replace(part)
+	if part != "" && !filepath.IsLocal(part) {
+		return "_"
+	}
+
+	return part
+}
+
+// WithMediaStore sets the MediaStore. The default is a LocalMediaStore writing to "media".
+func WithMediaStore(store MediaStore) MediaPipelineOption {
+	return func(pipeline *MediaPipeline) {
+		pipeline.store = store
+	}
+}
+
+// WithMediaPath sets the function used to decide where each media is stored.
+func WithMediaPath(path MediaPathFunc) MediaPipelineOption {
+	return func(pipeline *MediaPipeline) {
+		pipeline.path = path
+	}
+}
+
+// WithMediaDownloadCallback sets the callback called after each download.
+func WithMediaDownloadCallback(callback MediaDownloadCallback) MediaPipelineOption {
+	return func(pipeline *MediaPipeline) {
+		pipeline.callback = callback
+	}
+}
+
+// WithMediaPipelineWorkers sets the number of concurrent downloads and the queue size.
+func WithMediaPipelineWorkers(workers, queueSize int) MediaPipelineOption {
+	return func(pipeline *MediaPipeline) {
+		pipeline.workers = workers
+		pipeline.queueSize = queueSize
+	}
+}
+
+// WithMediaPipelineRetries sets how many times a failed download is retried and the delay
+// before the first retry. The delay grows linearly with every attempt.
+func WithMediaPipelineRetries(retries int, delay time.Duration) MediaPipelineOption {
+	return func(pipeline *MediaPipeline) {
+		pipeline.retries = retries
+		pipeline.retryDelay = delay
+	}
+}
+
+// NewMediaPipeline creates a MediaPipeline that downloads media using client and starts
+// its workers. Close must be called to stop them.
+func NewMediaPipeline(client *Client, options ...MediaPipelineOption) *MediaPipeline {
+	pipeline := &MediaPipeline{
+		client:     client,
+		store:      &LocalMediaStore{Dir: "media"},
+		path:       DefaultMediaPath,
+		workers:    DefaultMediaPipelineWorkers,
+		queueSize:  DefaultMediaPipelineQueueSize,
+		retries:    DefaultMediaPipelineRetries,
+		retryDelay: DefaultMediaPipelineRetryDelay,
+	}
+
+	for _, option := range options {
+		if option == nil {
+			continue
+		}
+		option(pipeline)
+	}
+
+	if pipeline.workers <= 0 {
+		pipeline.workers = 1
+	}
+
+	if pipeline.queueSize < 0 {
+		pipeline.queueSize = 0
+	}
+
+	pipeline.queue = make(chan *mediaJob, pipeline.queueSize)
+	for i := 0; i < pipeline.workers; i++ {
+		pipeline.wg.Add(1)
+		go pipeline.work()
+	}
+
+	return pipeline
+}
+
+// Hook returns a webhooks.OnMediaMessageHook that queues the media of every media message.
+// It returns ErrMediaPipelineQueueFull without blocking if the queue is full.
+func (pipeline *MediaPipeline) Hook() webhooks.OnMediaMessageHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+		mediaType webhooks.MessageType, media *models.MediaInfo,
+	) error {
+		if media == nil {
+			return nil
+		}
+
+		request := &MediaDownloadRequest{
+			MediaID:     media.ID,
+			MessageID:   mctx.ID,
+			MessageType: string(mediaType),
+			From:        mctx.From,
+			MimeType:    media.MimeType,
+			Filename:    media.Filename,
+		}
+
+		if nctx != nil && nctx.Metadata != nil {
+			request.PhoneNumberID = nctx.Metadata.PhoneNumberID
+		}
+
+		return pipeline.Enqueue(ctx, request)
+	}
+}
+
+// Enqueue queues a media for download. The values of ctx are kept but its cancellation
+// is not, so the download outlives the webhook request it was queued from.
+func (pipeline *MediaPipeline) Enqueue(ctx context.Context, request *MediaDownloadRequest) error {
+	pipeline.mu.RLock()
+	defer pipeline.mu.RUnlock()
+
+	if pipeline.closed {
+		return ErrMediaPipelineClosed
+	}
+
+	select {
+	case pipeline.queue <- &mediaJob{ctx: context.WithoutCancel(ctx), request: request}:
+		return nil
+	default:
+		return fmt.Errorf("%w: media %s", ErrMediaPipelineQueueFull, request.MediaID)
+	}
+}
+
+// Close stops accepting new media and waits for the queued downloads to finish or for
+// ctx to be done.
+func (pipeline *MediaPipeline) Close(ctx context.Context) error {
+	pipeline.closeOnce.Do(func() {
+		pipeline.mu.Lock()
+		pipeline.closed = true
+		close(pipeline.queue)
+		pipeline.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		pipeline.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("media pipeline: close: %w", ctx.Err())
+	}
+}
+
+func (pipeline *MediaPipeline) work() {
+	defer pipeline.wg.Done()
+	for job := range pipeline.queue {
+		media, err := pipeline.download(job.ctx, job.request)
+		if pipeline.callback != nil {
+			pipeline.callback(job.ctx, job.request, media, err)
+		}
+	}
+}
+
+func (pipeline *MediaPipeline) download(ctx context.Context, request *MediaDownloadRequest) (*DownloadedMedia, error) {
+	var err error
+	for attempt := 0; attempt <= pipeline.retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(time.Duration(attempt) * pipeline.retryDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return nil, fmt.Errorf("media pipeline: %s: %w", request.MediaID, ctx.Err())
+			case <-timer.C:
+			}
+		}
+
+		var media *DownloadedMedia
+		if media, err = pipeline.attempt(ctx, request); err == nil {
+			return media, nil
+		}
+
+		if attempt < pipeline.retries {
+			pipeline.client.bc.base.Report(ctx, &whttp.Event{
+				Kind:        whttp.EventRetry,
+				RequestName: "media pipeline",
+				Err:         err,
+				Attempt:     attempt + 1,
+			})
+		}
+	}
+
+	return nil, fmt.Errorf("media pipeline: %s: %w", request.MediaID, err)
+}
+
+func (pipeline *MediaPipeline) attempt(ctx context.Context, request *MediaDownloadRequest) (*DownloadedMedia, error) {
+	ctx, cancel := context.WithTimeout(ctx, MediaDownloadLinkTTL)
+	defer cancel()
+
+	info, err := pipeline.client.GetMediaInformation(ctx, request.MediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := info.MimeType
+	if mimeType == "" {
+		mimeType = request.MimeType
+	}
+
+	media := &DownloadedMedia{Request: request, MimeType: mimeType}
+
+	err = pipeline.client.fetchMedia(ctx, info.URL, func(response *http.Response) error {
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("%w: status %d", ErrMediaDownload, response.StatusCode)
+		}
+
+		hash := sha256.New()
+		counter := &countingReader{reader: io.TeeReader(response.Body, hash)}
+
+		location, err := pipeline.store.Save(ctx, pipeline.path(request, mimeType), mimeType, counter)
+		if err != nil {
+			return err
+		}
+
+		media.Location = location
+		media.Size = counter.n
+		media.Sha256 = hex.EncodeToString(hash.Sum(nil))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+
+	return media, nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
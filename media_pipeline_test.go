@@ -0,0 +1,172 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/piusalfred/whatsapp/pkg/models"
+	"github.com/piusalfred/whatsapp/webhooks"
+)
+
+func TestMediaPipeline(t *testing.T) {
+	t.Parallel()
+	content := []byte("voice note")
+	var downloads int32
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v16.0/media-1":
+			_, _ = fmt.Fprintf(w, `{"url":"%s/files/media-1","mime_type":"audio/ogg","id":"media-1"}`, server.URL)
+		case "/files/media-1":
+			// the first download fails so that the retry is exercised
+			if atomic.AddInt32(&downloads, 1) == 1 {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+			_, _ = w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		media *DownloadedMedia
+		err   error
+	}
+	results := make(chan result, 1)
+
+	dir := t.TempDir()
+	pipeline := NewMediaPipeline(client,
+		WithMediaStore(&LocalMediaStore{Dir: dir}),
+		WithMediaPipelineRetries(2, time.Millisecond),
+		WithMediaDownloadCallback(func(_ context.Context, _ *MediaDownloadRequest, media *DownloadedMedia, err error) {
+			results <- result{media: media, err: err}
+		}),
+	)
+
+	hook := pipeline.Hook()
+	nctx := &webhooks.NotificationContext{Metadata: &webhooks.Metadata{PhoneNumberID: "1234"}}
+	mctx := &webhooks.MessageContext{ID: "wamid.1", Type: "audio", From: "255700000000"}
+	audio := &models.MediaInfo{ID: "media-1", MimeType: "audio/ogg"}
+	if err = hook(context.TODO(), nctx, mctx, webhooks.AudioMessageType, audio); err != nil {
+		t.Fatalf("hook error = %v", err)
+	}
+
+	got := <-results
+	if got.err != nil {
+		t.Fatalf("download error = %v", got.err)
+	}
+
+	if err = pipeline.Close(context.TODO()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	sum := sha256.Sum256(content)
+	if got.media.Sha256 != hex.EncodeToString(sum[:]) || got.media.Size != int64(len(content)) {
+		t.Errorf("unexpected hash or size: %s %d", got.media.Sha256, got.media.Size)
+	}
+
+	if filepath.Dir(got.media.Location) != filepath.Join(dir, "1234", "audio") {
+		t.Errorf("unexpected location %s", got.media.Location)
+	}
+
+	stored, err := os.ReadFile(got.media.Location)
+	if err != nil || string(stored) != string(content) {
+		t.Errorf("stored content = %q, %v", stored, err)
+	}
+
+	if err = pipeline.Enqueue(context.TODO(), &MediaDownloadRequest{MediaID: "media-2"}); err == nil {
+		t.Errorf("expected enqueue on a closed pipeline to fail")
+	}
+}
+
+func TestMediaPathTraversal(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		request *MediaDownloadRequest
+		want    string
+	}{
+		{
+			name:    "regular",
+			request: &MediaDownloadRequest{PhoneNumberID: "1234", MessageType: "image", MediaID: "media-1"},
+			want:    "1234/image/media-1.png",
+		},
+		{
+			name:    "parent phone number id",
+			request: &MediaDownloadRequest{PhoneNumberID: "..", MessageType: "..", MediaID: "../../etc/passwd"},
+			want:    "_/_/.._.._etc_passwd.png",
+		},
+		{
+			name:    "backslashes",
+			request: &MediaDownloadRequest{PhoneNumberID: `..\..`, MessageType: "image", MediaID: `..\evil`},
+			want:    ".._../image/.._evil.png",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := DefaultMediaPath(tt.request, "image/png")
+			if got != tt.want || !filepath.IsLocal(filepath.FromSlash(got)) {
+				t.Errorf("DefaultMediaPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	store := &LocalMediaStore{Dir: filepath.Join(dir, "media")}
+	for _, path := range []string{"../outside.png", "1234/../../outside.png", "/etc/outside.png"} {
+		_, err := store.Save(context.TODO(), path, "image/png", strings.NewReader("content"))
+		if !errors.Is(err, ErrMediaPathNotLocal) {
+			t.Errorf("Save(%q) error = %v, want ErrMediaPathNotLocal", path, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "outside.png")); !os.IsNotExist(err) {
+		t.Errorf("a file was written outside the store directory: %v", err)
+	}
+}
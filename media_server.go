@@ -0,0 +1,381 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MediaLinkExpiresParam   = "expires"
+	MediaLinkSignatureParam = "signature"
+)
+
+var (
+	ErrMediaNotFound         = errors.New("media not found")
+	ErrMediaLinkExpired      = errors.New("media link expired")
+	ErrInvalidMediaSignature = errors.New("invalid media link signature")
+)
+
+type (
+	// HostedMedia is a media asset opened from a MediaStorage. Content must support seeking
+	// so that conditional and range requests can be served. ContentType and ETag are
+	// optional, when empty they are derived from the name and the content.
+	HostedMedia struct {
+		Content      io.ReadSeeker
+		ContentType  string
+		ETag         string
+		LastModified time.Time
+		Close        func() error
+	}
+
+	// MediaStorage is where the MediaHandler reads the media it serves from.
+	// Open must return ErrMediaNotFound if there is no media with the given name.
+	MediaStorage interface {
+		Open(ctx context.Context, name string) (*HostedMedia, error)
+	}
+
+	// MediaStorageFunc is a function that implements the MediaStorage interface.
+	MediaStorageFunc func(ctx context.Context, name string) (*HostedMedia, error)
+
+	// MediaURLSigner creates and verifies expiring HMAC-SHA256 signed links to media served
+	// by a MediaHandler. BaseURL is the public URL the MediaHandler is mounted at.
+	MediaURLSigner struct {
+		BaseURL string
+		Key     []byte
+		now     func() time.Time
+	}
+
+	// MediaHandler is a http.Handler that serves media to the WhatsApp servers when media
+	// messages are sent by link. It sets the Cache-Control header described in CacheOptions,
+	// the Content-Type, Last-Modified and ETag headers of every media, and answers
+	// conditional GET requests.
+	//
+	// The media name is the request path without the leading slash, use http.StripPrefix
+	// when mounting the handler under a prefix. If a MediaURLSigner is set, only requests
+	// with a valid and unexpired signature are served.
+	MediaHandler struct {
+		storage      MediaStorage
+		cacheOptions *CacheOptions
+		signer       *MediaURLSigner
+
+		// etags holds the etagEntry of the media hashed, by name.
+		etags sync.Map
+	}
+
+	// etagEntry is the ETag derived from the content of a media, valid while its
+	// modification time and size do not change.
+	etagEntry struct {
+		modtime time.Time
+		size    int64
+		etag    string
+	}
+
+	MediaHandlerOption func(*MediaHandler)
+)
+
+// Open calls fn(ctx, name).
+func (fn MediaStorageFunc) Open(ctx context.Context, name string) (*HostedMedia, error) {
+	return fn(ctx, name)
+}
+
+// FSMediaStorage returns a MediaStorage that reads media from fsys.
+func FSMediaStorage(fsys fs.FS) MediaStorage {
+	return MediaStorageFunc(func(_ context.Context, name string) (*HostedMedia, error) {
+		file, err := fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrMediaNotFound, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+
+		info, err := file.Stat()
+		if err != nil {
+			_ = file.Close()
+
+			return nil, fmt.Errorf("stat %s: %w", name, err)
+		}
+
+		if info.IsDir() {
+			_ = file.Close()
+
+			return nil, fmt.Errorf("%w: %s is a directory", ErrMediaNotFound, name)
+		}
+
+		media := &HostedMedia{
+			LastModified: info.ModTime(),
+			Close:        file.Close,
+		}
+
+		if rs, ok := file.(io.ReadSeeker); ok {
+			media.Content = rs
+
+			return media, nil
+		}
+
+		data, err := io.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		media.Content = bytes.NewReader(data)
+		media.Close = nil
+
+		return media, nil
+	})
+}
+
+// NewMediaURLSigner creates a MediaURLSigner for the handler mounted at baseURL.
+func NewMediaURLSigner(baseURL string, key []byte) *MediaURLSigner {
+	return &MediaURLSigner{
+		BaseURL: baseURL,
+		Key:     key,
+		now:     time.Now,
+	}
+}
+
+// SignedURL returns a link to the named media that is valid for ttl. The link can be
+// used as MediaMessage.MediaLink.
+func (signer *MediaURLSigner) SignedURL(name string, ttl time.Duration) (string, error) {
+	expires := strconv.FormatInt(signer.time().Add(ttl).Unix(), 10)
+
+	link, err := url.JoinPath(signer.BaseURL, name)
+	if err != nil {
+		return "", fmt.Errorf("media url signer: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set(MediaLinkExpiresParam, expires)
+	query.Set(MediaLinkSignatureParam, signer.signature(name, expires))
+
+	return link + "?" + query.Encode(), nil
+}
+
+// Verify checks that signature was created by SignedURL for the named media and that
+// the link has not expired.
+func (signer *MediaURLSigner) Verify(name, expires, signature string) error {
+	expected, err := hex.DecodeString(signer.signature(name, expires))
+	if err != nil {
+		return fmt.Errorf("media url signer: %w", err)
+	}
+
+	actual, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, actual) {
+		return ErrInvalidMediaSignature
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidMediaSignature
+	}
+
+	if signer.time().After(time.Unix(unix, 0)) {
+		return ErrMediaLinkExpired
+	}
+
+	return nil
+}
+
+func (signer *MediaURLSigner) signature(name, expires string) string {
+	mac := hmac.New(sha256.New, signer.Key)
+	mac.Write([]byte(strings.TrimPrefix(name, "/")))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(expires))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (signer *MediaURLSigner) time() time.Time {
+	if signer.now == nil {
+		return time.Now()
+	}
+
+	return signer.now()
+}
+
+// WithMediaCacheOptions sets the Cache-Control header sent with every media. If CacheControl
+// is empty and Expires is set, Cache-Control is set to max-age=Expires. LastModified and
+// ETag are not used, the validators are derived from every media.
+func WithMediaCacheOptions(options *CacheOptions) MediaHandlerOption {
+	return func(handler *MediaHandler) {
+		handler.cacheOptions = options
+	}
+}
+
+// WithMediaURLSigner makes the handler only serve requests signed by signer.
+func WithMediaURLSigner(signer *MediaURLSigner) MediaHandlerOption {
+	return func(handler *MediaHandler) {
+		handler.signer = signer
+	}
+}
+
+// NewMediaHandler creates a MediaHandler that serves media from storage.
+func NewMediaHandler(storage MediaStorage, options ...MediaHandlerOption) *MediaHandler {
+	handler := &MediaHandler{storage: storage}
+	for _, option := range options {
+		if option == nil {
+			continue
+		}
+		option(handler)
+	}
+
+	return handler
+}
+
+// ServeHTTP implements http.Handler.
+func (handler *MediaHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		writer.Header().Set("Allow", "GET, HEAD")
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+request.URL.Path), "/")
+	if name == "" {
+		writer.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	if handler.signer != nil {
+		query := request.URL.Query()
+		err := handler.signer.Verify(name, query.Get(MediaLinkExpiresParam), query.Get(MediaLinkSignatureParam))
+		if err != nil {
+			writer.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+	}
+
+	media, err := handler.storage.Open(request.Context(), name)
+	if errors.Is(err, ErrMediaNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if media.Close != nil {
+		defer func() { _ = media.Close() }()
+	}
+
+	if err = handler.setHeaders(writer.Header(), name, media); err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	// http.ServeContent answers If-None-Match, If-Modified-Since and Range requests
+	// using the ETag set above and the modification time of the media.
+	http.ServeContent(writer, request, name, media.LastModified, media.Content)
+}
+
+func (handler *MediaHandler) setHeaders(header http.Header, name string, media *HostedMedia) error {
+	contentType := media.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(name))
+	}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	etag := media.ETag
+	if etag == "" {
+		var err error
+		if etag, err = handler.contentETag(name, media); err != nil {
+			return err
+		}
+	}
+
+	options := handler.cacheOptions
+	if options != nil {
+		if options.CacheControl != "" {
+			header.Set("Cache-Control", options.CacheControl)
+		} else if options.Expires > 0 {
+			header.Set("Cache-Control", fmt.Sprintf("max-age=%d", options.Expires))
+		}
+	}
+
+	if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = strconv.Quote(etag)
+	}
+	header.Set("ETag", etag)
+
+	return nil
+}
+
+// contentETag returns the ETag derived from the content of media. It is hashed once and
+// reused while the modification time and the size of the media do not change, media
+// without a modification time are hashed on every request.
+func (handler *MediaHandler) contentETag(name string, media *HostedMedia) (string, error) {
+	size, err := media.Content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", fmt.Errorf("media handler: seek %s: %w", name, err)
+	}
+	if _, err = media.Content.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("media handler: seek %s: %w", name, err)
+	}
+
+	cacheable := !media.LastModified.IsZero()
+	if cached, ok := handler.etags.Load(name); ok && cacheable {
+		entry := cached.(*etagEntry) //nolint:forcetypeassert
+		if entry.size == size && entry.modtime.Equal(media.LastModified) {
+			return entry.etag, nil
+		}
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, media.Content); err != nil {
+		return "", fmt.Errorf("media handler: hash %s: %w", name, err)
+	}
+	if _, err = media.Content.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("media handler: seek %s: %w", name, err)
+	}
+	etag := hex.EncodeToString(hash.Sum(nil)[:16]) //nolint:gomnd
+
+	if cacheable {
+		handler.etags.Store(name, &etagEntry{modtime: media.LastModified, size: size, etag: etag})
+	}
+
+	return etag, nil
+}
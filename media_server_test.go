@@ -0,0 +1,177 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMediaHandler(t *testing.T) {
+	t.Parallel()
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"images/logo.png": &fstest.MapFile{Data: []byte("not really a png"), ModTime: modtime},
+	}
+
+	handler := NewMediaHandler(FSMediaStorage(fsys), WithMediaCacheOptions(&CacheOptions{Expires: 604800}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/images/logo.png", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+
+	header := recorder.Header()
+	if got := header.Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", got)
+	}
+
+	if got := header.Get("Cache-Control"); got != "max-age=604800" {
+		t.Errorf("Cache-Control = %q, want max-age=604800", got)
+	}
+
+	if got := header.Get("Last-Modified"); got != modtime.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q, want %q", got, modtime.Format(http.TimeFormat))
+	}
+
+	etag := header.Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected an ETag header")
+	}
+
+	conditional := httptest.NewRequest(http.MethodGet, "/images/logo.png", nil)
+	conditional.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, conditional)
+
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("expected status 304 for matching ETag, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/images/missing.png", nil))
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", recorder.Code)
+	}
+}
+
+func TestMediaHandlerSignedURL(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{"brochure.pdf": &fstest.MapFile{Data: []byte("%PDF-1.4")}}
+	signer := NewMediaURLSigner("https://media.example.com/wa", []byte("secret"))
+	handler := http.StripPrefix("/wa", NewMediaHandler(FSMediaStorage(fsys), WithMediaURLSigner(signer)))
+
+	link, err := signer.SignedURL("brochure.pdf", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected status 200 for signed link, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/wa/brochure.pdf", nil))
+
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for unsigned link, got %d", recorder.Code)
+	}
+
+	signer.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if err = signer.Verify("brochure.pdf", u.Query().Get(MediaLinkExpiresParam),
+		u.Query().Get(MediaLinkSignatureParam)); err == nil {
+		t.Errorf("expected expired link to be rejected")
+	}
+}
+
+// countingReadSeeker counts the reads of the content of a media.
+type countingReadSeeker struct {
+	*bytes.Reader
+	reads *atomic.Int64
+}
+
+func (r countingReadSeeker) Read(p []byte) (int, error) {
+	r.reads.Add(1)
+
+	return r.Reader.Read(p) //nolint:wrapcheck
+}
+
+func TestMediaHandlerValidators(t *testing.T) {
+	t.Parallel()
+	modtime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	var reads atomic.Int64
+	storage := MediaStorageFunc(func(_ context.Context, name string) (*HostedMedia, error) {
+		return &HostedMedia{
+			Content:      countingReadSeeker{Reader: bytes.NewReader([]byte("content of " + name)), reads: &reads},
+			LastModified: modtime,
+		}, nil
+	})
+	handler := NewMediaHandler(storage, WithMediaCacheOptions(&CacheOptions{ETag: "same", Expires: 60}))
+
+	serve := func(method, name, etag string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/"+name, nil)
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	etagA := serve(http.MethodHead, "a.png", "").Header().Get("ETag")
+	etagB := serve(http.MethodHead, "b.png", "").Header().Get("ETag")
+	if etagA == etagB {
+		t.Fatalf("media a and b have the same ETag %s", etagA)
+	}
+
+	if code := serve(http.MethodGet, "b.png", etagA).Code; code != http.StatusOK {
+		t.Errorf("GET b with the ETag of a: status = %d, want 200", code)
+	}
+
+	if code := serve(http.MethodGet, "a.png", etagA).Code; code != http.StatusNotModified {
+		t.Errorf("GET a with its ETag: status = %d, want 304", code)
+	}
+
+	// the ETag of a is reused while its modification time and size do not change
+	before := reads.Load()
+	serve(http.MethodHead, "a.png", "")
+	if reads.Load() != before {
+		t.Errorf("the content of a was hashed again")
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/metrics"
+	"github.com/piusalfred/whatsapp/pkg/models"
+)
+
+const (
+	// MetricOperations counts the operations by name, status and WhatsApp error code.
+	MetricOperations = "whatsapp_operations_total"
+
+	// MetricOperationDuration is the duration of the operations by name and status.
+	MetricOperationDuration = "whatsapp_operation_duration_seconds"
+
+	// MetricSendDuration is the duration of the message sends by message type and status.
+	MetricSendDuration = "whatsapp_send_duration_seconds"
+)
+
+// WithMetrics records the calls of the client in registry, see WithBaseClientMetrics. It
+// has no effect on a BaseClient set with WithBaseClient, which is configured by its owner,
+// nor on the clients of a Registry, see WithRegistryMetrics.
+func WithMetrics(registry *metrics.Registry) ClientOption {
+	return func(client *Client) {
+		client.metrics = registry
+	}
+}
+
+// WithBaseClientMetrics records the calls of the base client in registry: every operation
+// with MetricsOperationMiddleware, every message send with MetricsSendMiddleware and every
+// http call with whttp.WithMetrics. The metrics are also added to a client passed with
+// WithBaseHTTPClient.
+func WithBaseClientMetrics(registry *metrics.Registry) BaseClientOption {
+	return func(client *BaseClient) {
+		client.metrics = registry
+	}
+}
+
+// MetricsOperationMiddleware returns an OperationMiddleware that counts the operations and
+// records their duration in registry. The status is "ok", the http status code of a
+// whttp.ResponseError or "error" for the other failures, and the error code is the code of
+// the WhatsApp error in a ResponseError.
+func MetricsOperationMiddleware(registry *metrics.Registry) OperationMiddleware {
+	operations := registry.Counter(MetricOperations, "Calls made to the WhatsApp API.",
+		"operation", "status", "error_code")
+	durations := registry.Histogram(MetricOperationDuration, "Duration of the calls made to the WhatsApp API.",
+		nil, "operation", "status")
+
+	return func(next OperationHandler) OperationHandler {
+		return OperationHandlerFunc(func(ctx context.Context, operation *Operation) error {
+			start := time.Now()
+			err := next.Handle(ctx, operation)
+
+			status, code := callStatus(err)
+			operations.Inc(operation.Name, status, code)
+			durations.Observe(time.Since(start).Seconds(), operation.Name, status)
+
+			return err
+		})
+	}
+}
+
+// MetricsSendMiddleware returns a SendMiddleware that records the duration of the message
+// sends by message type and status in registry, with the statuses of
+// MetricsOperationMiddleware.
+func MetricsSendMiddleware(registry *metrics.Registry) SendMiddleware {
+	durations := registry.Histogram(MetricSendDuration, "Duration of the messages sent to the WhatsApp API.",
+		nil, "message_type", "status")
+
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, req *whttp.RequestContext,
+			message *models.Message,
+		) (*ResponseMessage, error) {
+			start := time.Now()
+			response, err := next.Send(ctx, req, message)
+
+			var messageType string
+			if message != nil {
+				messageType = message.Type
+			}
+			status, _ := callStatus(err)
+			durations.Observe(time.Since(start).Seconds(), messageType, status)
+
+			return response, err //nolint:wrapcheck
+		})
+	}
+}
+
+// setMetrics records the calls of the base client in registry, outermost so that the
+// durations cover the other middleware.
+func (c *BaseClient) setMetrics(registry *metrics.Registry) {
+	c.base.SetMetrics(registry)
+	c.opmw = append([]OperationMiddleware{MetricsOperationMiddleware(registry)}, c.opmw...)
+	c.mw = append([]SendMiddleware{MetricsSendMiddleware(registry)}, c.mw...)
+}
+
+// callStatus returns the status and the WhatsApp error code of a call that returned err.
+func callStatus(err error) (string, string) {
+	if err == nil {
+		return "ok", ""
+	}
+
+	var responseErr *whttp.ResponseError
+	if !errors.As(err, &responseErr) {
+		return "error", ""
+	}
+
+	var code string
+	if responseErr.Err != nil && responseErr.Err.Code != 0 {
+		code = strconv.Itoa(responseErr.Err.Code)
+	}
+
+	return strconv.Itoa(responseErr.Code), code
+}
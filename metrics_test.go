@@ -0,0 +1,87 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/metrics"
+)
+
+func TestClientMetrics(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/messages") {
+			_, _ = w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"invalid parameter","code":100}}`))
+	}))
+	defer server.Close()
+
+	registry := metrics.NewRegistry()
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"},
+		WithMetrics(registry))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.SendText(context.TODO(), "255700000000", &TextMessage{Message: "hi"}); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	if _, err = client.GetMediaInformation(context.TODO(), "media"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	operations := registry.Counter(MetricOperations, "", "operation", "status", "error_code")
+	if got := operations.Value("send text", "ok", ""); got != 1 {
+		t.Errorf("send text operations = %v, want 1", got)
+	}
+
+	if got := operations.Value("get media", "400", "100"); got != 1 {
+		t.Errorf("get media operations = %v, want 1", got)
+	}
+
+	if got := registry.Histogram(MetricSendDuration, "", nil, "message_type", "status").Count("text", "ok"); got != 1 {
+		t.Errorf("send durations = %v, want 1", got)
+	}
+
+	if got := registry.Counter(whttp.MetricResponses, "", "operation", "status").Value("get media", "400"); got != 1 {
+		t.Errorf("http responses = %v, want 1", got)
+	}
+
+	var b strings.Builder
+	if err = registry.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `whatsapp_operations_total{operation="get media",status="400",error_code="100"} 1`
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("exposition does not contain %s:\n%s", want, b.String())
+	}
+}
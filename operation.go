@@ -0,0 +1,117 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+type (
+	// Operation is a single call to the API as seen by an OperationMiddleware. Name is the
+	// name of the operation, for example "send template" or "list qr codes", Request is the
+	// request about to be made and Response points to the typed value the response is decoded
+	// into, for example a *ResponseMessage. Response is nil for operations that do not
+	// decode a body, and Decoder is set for the ones that decode it themselves, like media
+	// downloads.
+	Operation struct {
+		Name     string
+		Request  *whttp.Request
+		Response any
+		Decoder  whttp.ResponseDecoder
+	}
+
+	// OperationHandler performs an Operation.
+	OperationHandler interface {
+		Handle(ctx context.Context, operation *Operation) error
+	}
+
+	// OperationHandlerFunc is a function that implements the OperationHandler interface.
+	OperationHandlerFunc func(ctx context.Context, operation *Operation) error
+
+	// OperationMiddleware wraps an OperationHandler. Every outbound call made through a
+	// BaseClient, and so through every Client sharing it, passes through its operation
+	// middleware, which makes it the place for logging, retries, metrics and policies.
+	//
+	//	logging := func(next whatsapp.OperationHandler) whatsapp.OperationHandler {
+	//		return whatsapp.OperationHandlerFunc(func(ctx context.Context, op *whatsapp.Operation) error {
+	//			err := next.Handle(ctx, op)
+	//			slog.InfoContext(ctx, "whatsapp call", "operation", op.Name, "error", err)
+	//
+	//			return err
+	//		})
+	//	}
+	//	base := whatsapp.NewBaseClient(whatsapp.WithOperationMiddleware(logging))
+	OperationMiddleware func(next OperationHandler) OperationHandler
+)
+
+// Handle calls the function that implements the OperationHandler interface.
+func (fn OperationHandlerFunc) Handle(ctx context.Context, operation *Operation) error {
+	return fn(ctx, operation)
+}
+
+// WithOperationMiddleware adds middleware that every operation of the base client passes
+// through. The first middleware is the outermost one.
+func WithOperationMiddleware(middleware ...OperationMiddleware) BaseClientOption {
+	return func(client *BaseClient) {
+		client.opmw = append(client.opmw, middleware...)
+	}
+}
+
+// WrapOperationHandler wraps handler with the middleware, the first one being the outermost.
+func WrapOperationHandler(handler OperationHandler, middleware ...OperationMiddleware) OperationHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] != nil {
+			handler = middleware[i](handler)
+		}
+	}
+
+	return handler
+}
+
+// do runs request through the operation middleware and decodes the response into v.
+func (c *BaseClient) do(ctx context.Context, request *whttp.Request, v any) error {
+	return c.doWithDecoder(ctx, request, nil, v)
+}
+
+// doWithDecoder is like do but decodes the response with decoder when it is not nil.
+func (c *BaseClient) doWithDecoder(ctx context.Context, request *whttp.Request, decoder whttp.ResponseDecoder,
+	v any,
+) error {
+	ctx, cancel, request := applyCallOptions(ctx, request)
+	defer cancel()
+
+	operation := &Operation{Request: request, Response: v, Decoder: decoder}
+	if request != nil && request.Context != nil {
+		operation.Name = request.Context.Name
+	}
+
+	return WrapOperationHandler(OperationHandlerFunc(c.handle), c.opmw...).Handle(ctx, operation)
+}
+
+// handle is the innermost OperationHandler, it makes the http call.
+func (c *BaseClient) handle(ctx context.Context, operation *Operation) error {
+	if operation.Decoder != nil {
+		return c.base.DoWithDecoder(ctx, operation.Request, operation.Decoder, operation.Response) //nolint:wrapcheck
+	}
+
+	return c.base.Do(ctx, operation.Request, operation.Response) //nolint:wrapcheck
+}
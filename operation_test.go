@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOperationMiddleware(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first call fails so that the retry middleware is exercised
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"success":true,"messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	var operations []string
+	var responses []any
+	record := func(next OperationHandler) OperationHandler {
+		return OperationHandlerFunc(func(ctx context.Context, op *Operation) error {
+			err := next.Handle(ctx, op)
+			operations = append(operations, op.Name)
+			responses = append(responses, op.Response)
+
+			return err
+		})
+	}
+
+	retry := func(next OperationHandler) OperationHandler {
+		return OperationHandlerFunc(func(ctx context.Context, op *Operation) error {
+			if err := next.Handle(ctx, op); err != nil {
+				return next.Handle(ctx, op)
+			}
+
+			return nil
+		})
+	}
+
+	base := NewBaseClient(WithOperationMiddleware(record, retry))
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"},
+		WithBaseClient(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.SendText(context.TODO(), "255700000000", &TextMessage{Message: "hi"}); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	if _, err = client.MarkMessageRead(context.TODO(), "wamid.1"); err != nil {
+		t.Fatalf("MarkMessageRead() error = %v", err)
+	}
+
+	if _, err = client.DeleteQRCode(context.TODO(), "code"); err != nil {
+		t.Fatalf("DeleteQRCode() error = %v", err)
+	}
+
+	want := []string{"send text", "mark message read", "delete qr code"}
+	if len(operations) != len(want) {
+		t.Fatalf("operations = %v, want %v", operations, want)
+	}
+
+	for i, name := range want {
+		if operations[i] != name {
+			t.Errorf("operation %d = %q, want %q", i, operations[i], name)
+		}
+	}
+
+	if _, ok := responses[0].(*ResponseMessage); !ok {
+		t.Errorf("expected a typed *ResponseMessage, got %T", responses[0])
+	}
+
+	if _, ok := responses[1].(*StatusResponse); !ok {
+		t.Errorf("expected a typed *StatusResponse, got %T", responses[1])
+	}
+}
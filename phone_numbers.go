@@ -53,7 +53,9 @@ type (
 	}
 
 	Paging struct {
-		Cursors *Cursors `json:"cursors,omitempty"`
+		Cursors  *Cursors `json:"cursors,omitempty"`
+		Next     string   `json:"next,omitempty"`
+		Previous string   `json:"previous,omitempty"`
 	}
 
 	Cursors struct {
@@ -89,14 +91,18 @@ type (
 // API call, you will receive your verification code via the method you selected. To finish the verification
 // process, include your code in the VerifyCode method.
 func (client *Client) RequestVerificationCode(ctx context.Context,
-	codeMethod VerificationMethod, language string,
+	codeMethod VerificationMethod, language string, opts ...CallOption,
 ) error {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	reqCtx := &whttp.RequestContext{
-		Name:          "request code",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Endpoints:     []string{"request_code"},
+		TokenSource:       client.tokens,
+		Name:              "request code",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, "request_code"),
 	}
 
 	params := &whttp.Request{
@@ -104,11 +110,11 @@ func (client *Client) RequestVerificationCode(ctx context.Context,
 		Method:  http.MethodPost,
 		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
 		Query:   nil,
-		Bearer:  client.config.AccessToken,
+		Bearer:  config.AccessToken,
 		Form:    map[string]string{"code_method": string(codeMethod), "language": language},
 		Payload: nil,
 	}
-	err := client.bc.base.Do(ctx, params, nil)
+	err := client.bc.do(ctx, params, nil)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -117,25 +123,29 @@ func (client *Client) RequestVerificationCode(ctx context.Context,
 }
 
 // VerifyCode should be run to verify the code retrieved by RequestVerificationCode.
-func (client *Client) VerifyCode(ctx context.Context, code string) (*StatusResponse, error) {
+func (client *Client) VerifyCode(ctx context.Context, code string, opts ...CallOption) (*StatusResponse, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	reqCtx := &whttp.RequestContext{
-		Name:          "verify code",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Endpoints:     []string{"verify_code"},
+		TokenSource:       client.tokens,
+		Name:              "verify code",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, "verify_code"),
 	}
 	params := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodPost,
 		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
 		Query:   nil,
-		Bearer:  client.config.AccessToken,
+		Bearer:  config.AccessToken,
 		Form:    map[string]string{"code": code},
 	}
 
 	var resp StatusResponse
-	err := client.bc.base.Do(ctx, params, &resp)
+	err := client.bc.do(ctx, params, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -208,19 +218,25 @@ func (client *Client) VerifyCode(ctx context.Context, code string) (*StatusRespo
 //		}
 //	   }
 //	}
-func (client *Client) ListPhoneNumbers(ctx context.Context, filters []*FilterParams) (*PhoneNumbersList, error) {
+func (client *Client) ListPhoneNumbers(ctx context.Context, filters []*FilterParams,
+	opts ...CallOption,
+) (*PhoneNumbersList, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	reqCtx := &whttp.RequestContext{
-		Name:          "list phone numbers",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.BusinessAccountID,
-		Endpoints:     []string{"phone_numbers"},
+		TokenSource:       client.tokens,
+		Name:              "list phone numbers",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		BusinessAccountID: config.BusinessAccountID,
+		Route: whttp.BusinessAccountRoute(config.BusinessAccountID, "phone_numbers").
+			WithAuth(whttp.AuthQuery),
 	}
 
 	params := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodGet,
-		Query:   map[string]string{"access_token": client.config.AccessToken},
+		Query:   map[string]string{},
 	}
 	if filters != nil {
 		p := filters
@@ -231,7 +247,7 @@ func (client *Client) ListPhoneNumbers(ctx context.Context, filters []*FilterPar
 		params.Query["filtering"] = string(jsonParams)
 	}
 	var phoneNumbersList PhoneNumbersList
-	err := client.bc.base.Do(ctx, params, &phoneNumbersList)
+	err := client.bc.do(ctx, params, &phoneNumbersList)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -240,22 +256,24 @@ func (client *Client) ListPhoneNumbers(ctx context.Context, filters []*FilterPar
 }
 
 // PhoneNumberByID returns the phone number associated with the given ID.
-func (client *Client) PhoneNumberByID(ctx context.Context) (*PhoneNumber, error) {
+func (client *Client) PhoneNumberByID(ctx context.Context, opts ...CallOption) (*PhoneNumber, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	reqCtx := &whttp.RequestContext{
-		Name:          "get phone number by id",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
+		TokenSource:       client.tokens,
+		Name:              "get phone number by id",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID),
 	}
 	request := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodGet,
-		Headers: map[string]string{
-			"Authorization": "Bearer " + client.config.AccessToken,
-		},
 	}
 	var phoneNumber PhoneNumber
-	if err := client.bc.base.Do(ctx, request, &phoneNumber); err != nil {
+	if err := client.bc.do(ctx, request, &phoneNumber); err != nil {
 		return nil, fmt.Errorf("get phone muber by id: %w", err)
 	}
 
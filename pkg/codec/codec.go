@@ -0,0 +1,126 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package codec defines how the library encodes and decodes JSON, so that a faster or a
+// stricter implementation than encoding/json can be plugged into pkg/http, the clients and
+// the webhooks listener.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrUnknownField is returned by a strict codec when the data has a field that the
+	// destination does not.
+	ErrUnknownField = errors.New("unknown field")
+
+	// ErrTrailingData is returned by a strict codec when the data has more than one value.
+	ErrTrailingData = errors.New("trailing data after the value")
+)
+
+type (
+	// Codec encodes and decodes values. Implementations must be safe for concurrent use.
+	Codec interface {
+		Marshal(v any) ([]byte, error)
+		Unmarshal(data []byte, v any) error
+		NewDecoder(r io.Reader) Decoder
+	}
+
+	// Decoder decodes values from a stream.
+	Decoder interface {
+		Decode(v any) error
+	}
+
+	// JSON is a Codec that uses encoding/json. With Strict set, unknown fields are reported
+	// with ErrUnknownField instead of being ignored.
+	JSON struct {
+		Strict bool
+	}
+
+	jsonDecoder struct {
+		decoder *json.Decoder
+	}
+)
+
+// Default is the codec used when none is configured.
+var Default Codec = JSON{} //nolint:gochecknoglobals
+
+// Strict returns a JSON codec that reports unknown fields.
+func Strict() Codec {
+	return JSON{Strict: true}
+}
+
+// OrDefault returns c, or Default when c is nil.
+func OrDefault(c Codec) Codec {
+	if c == nil {
+		return Default
+	}
+
+	return c
+}
+
+// Marshal encodes v.
+func (codec JSON) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v) //nolint:wrapcheck
+}
+
+// Unmarshal decodes data into v. A strict codec also reports data after the value with
+// ErrTrailingData.
+func (codec JSON) Unmarshal(data []byte, v any) error {
+	if !codec.Strict {
+		return json.Unmarshal(data, v) //nolint:wrapcheck
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := (&jsonDecoder{decoder: decoder}).Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return ErrTrailingData
+	}
+
+	return nil
+}
+
+// NewDecoder returns a Decoder that reads from r.
+func (codec JSON) NewDecoder(r io.Reader) Decoder {
+	decoder := json.NewDecoder(r)
+	if codec.Strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	return &jsonDecoder{decoder: decoder}
+}
+
+func (d *jsonDecoder) Decode(v any) error {
+	err := d.decoder.Decode(v)
+	if err != nil && strings.HasPrefix(err.Error(), "json: unknown field") {
+		return fmt.Errorf("%w: %w", ErrUnknownField, err)
+	}
+
+	return err //nolint:wrapcheck
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package codec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONUnmarshal(t *testing.T) {
+	t.Parallel()
+	type message struct {
+		ID string `json:"id"`
+	}
+	tests := []struct {
+		name    string
+		codec   Codec
+		data    string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "default ignores unknown fields",
+			codec: Default,
+			data:  `{"id":"wamid.1","unknown":true}`,
+			want:  "wamid.1",
+		},
+		{
+			name:  "strict with known fields",
+			codec: Strict(),
+			data:  `{"id":"wamid.1"}`,
+			want:  "wamid.1",
+		},
+		{
+			name:    "strict reports unknown fields",
+			codec:   Strict(),
+			data:    `{"id":"wamid.1","unknown":true}`,
+			wantErr: ErrUnknownField,
+		},
+		{
+			name:    "strict reports trailing data",
+			codec:   Strict(),
+			data:    `{"id":"wamid.1"} {"id":"wamid.2"}`,
+			wantErr: ErrTrailingData,
+		},
+		{
+			name:    "strict reports trailing garbage",
+			codec:   Strict(),
+			data:    `{"id":"wamid.1"}x`,
+			wantErr: ErrTrailingData,
+		},
+		{
+			name:  "strict allows trailing whitespace",
+			codec: Strict(),
+			data:  "{\"id\":\"wamid.1\"}\n",
+			want:  "wamid.1",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got message
+			err := tt.codec.Unmarshal([]byte(tt.data), &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.ID != tt.want {
+				t.Errorf("Unmarshal() id = %q, want %q", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONNewDecoder(t *testing.T) {
+	t.Parallel()
+	decoder := Strict().NewDecoder(strings.NewReader(`{"id":"1"} {"name":"2"}`))
+
+	var v struct {
+		ID string `json:"id"`
+	}
+	if err := decoder.Decode(&v); err != nil || v.ID != "1" {
+		t.Fatalf("Decode() = %q, %v", v.ID, err)
+	}
+
+	if err := decoder.Decode(&v); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("Decode() error = %v, want ErrUnknownField", err)
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	t.Parallel()
+	if OrDefault(nil) != Default {
+		t.Errorf("OrDefault(nil) is not Default")
+	}
+
+	if c := Strict(); OrDefault(c) != c {
+		t.Errorf("OrDefault() did not return the given codec")
+	}
+}
@@ -0,0 +1,516 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package errors
+
+import (
+	"errors"
+)
+
+// Category groups the error codes of the Cloud API by what went wrong.
+type Category string
+
+const (
+	CategoryUnknown       Category = "unknown"
+	CategoryAuthorization Category = "authorization"
+	CategoryThrottling    Category = "throttling"
+	CategoryIntegrity     Category = "integrity"
+	CategoryReengagement  Category = "reengagement"
+	CategoryRecipient     Category = "recipient"
+	CategoryTemplate      Category = "template"
+	CategoryMedia         Category = "media"
+	CategoryParameter     Category = "parameter"
+	CategoryServer        Category = "server"
+	CategoryRegistration  Category = "registration"
+	CategoryBusiness      Category = "business"
+	CategoryFlow          Category = "flow"
+)
+
+// Error codes of the Cloud API that callers commonly handle on their own. See Catalogue
+// for the complete list.
+const (
+	CodeAuthException           = 0
+	CodeAPIUnknown              = 1
+	CodeAPIService              = 2
+	CodeTooManyCalls            = 4
+	CodeInvalidParameter        = 100
+	CodeAccessTokenExpired      = 190
+	CodeTemporarilyBlocked      = 368
+	CodeRateLimitIssues         = 80007
+	CodeCloudAPIThroughput      = 130429
+	CodeSomethingWentWrong      = 131000
+	CodeServiceUnavailable      = 131016
+	CodeMessageUndeliverable    = 131026
+	CodeRecipientNotAllowed     = 131030
+	CodeAccountLocked           = 131031
+	CodeBusinessEligibility     = 131042
+	CodeReengagementMessage     = 131047
+	CodeMediaDownloadError      = 131052
+	CodeMediaUploadError        = 131053
+	CodePairRateLimitHit        = 131056
+	CodeAccountInMaintenance    = 131057
+	CodeTemplateParamMismatch   = 132000
+	CodeTemplateDoesNotExist    = 132001
+	CodeTemplateHydratedTooLong = 132005
+	CodeTemplateDisabled        = 132016
+	CodeServerUnavailable       = 133004
+	CodePhoneNumberNotVerified  = 133010
+	CodeRetryAfterFewMinutes    = 133015
+)
+
+const (
+	permissionCodeRangeStart = 200
+	permissionCodeRangeEnd   = 299
+	templateCodeRangeStart   = 132000
+	templateCodeRangeEnd     = 132016
+)
+
+// CodeInfo describes an error code of the Cloud API.
+type CodeInfo struct {
+	Code        int
+	Category    Category
+	Title       string
+	Description string
+
+	// Retryable is true when the same request may succeed later without changes, possibly
+	// after a back off.
+	Retryable bool
+
+	// Action is what the caller is advised to do about the error.
+	Action string
+}
+
+// Catalogue holds the documented error codes of the Cloud API, see DeveloperErrorDescLink.
+// Codes 200 to 299 are API permission errors and are not listed one by one, use Lookup.
+var Catalogue = map[int]CodeInfo{ //nolint:gochecknoglobals
+	CodeAuthException: {
+		Category:    CategoryAuthorization,
+		Title:       "AuthException",
+		Description: "We were unable to authenticate the app user.",
+		Action:      "Get a new access token.",
+	},
+	CodeAPIUnknown: {
+		Category:    CategoryServer,
+		Title:       "API Unknown",
+		Description: "Invalid request or possible server error.",
+		Retryable:   true,
+		Action:      "Check the WhatsApp Business Platform status page and retry later.",
+	},
+	CodeAPIService: {
+		Category:    CategoryServer,
+		Title:       "API Service",
+		Description: "Temporary due to downtime or due to being overloaded.",
+		Retryable:   true,
+		Action:      "Check the WhatsApp Business Platform status page and retry later.",
+	},
+	3: {
+		Category:    CategoryAuthorization,
+		Title:       "API Method",
+		Description: "Capability or permissions issue.",
+		Action:      "Check that the app has the required permissions.",
+	},
+	CodeTooManyCalls: {
+		Category:    CategoryThrottling,
+		Title:       "Too many calls",
+		Description: "The app has reached its API call rate limit.",
+		Retryable:   true,
+		Action:      "Load the app dashboard, reduce the frequency or amount of calls and retry later.",
+	},
+	10: {
+		Category:    CategoryAuthorization,
+		Title:       "Permission Denied",
+		Description: "Permission is either not granted or has been removed.",
+		Action:      "Check that the app has the required permissions and that the phone number is allowed.",
+	},
+	33: {
+		Category:    CategoryParameter,
+		Title:       "Parameter value is not valid",
+		Description: "The business phone number has been deleted.",
+		Action:      "Verify that the business phone number is correct.",
+	},
+	CodeInvalidParameter: {
+		Category:    CategoryParameter,
+		Title:       "Invalid parameter",
+		Description: "The request included one or more unsupported or misspelled parameters.",
+		Action:      "Check the endpoint reference for the supported parameters.",
+	},
+	CodeAccessTokenExpired: {
+		Category:    CategoryAuthorization,
+		Title:       "Access token has expired",
+		Description: "The access token has expired.",
+		Action:      "Get a new access token.",
+	},
+	CodeTemporarilyBlocked: {
+		Category:    CategoryIntegrity,
+		Title:       "Temporarily blocked for policies violations",
+		Description: "The WhatsApp Business Account is restricted from messaging due to policy violations.",
+		Action:      "See the policy enforcement documentation.",
+	},
+	CodeRateLimitIssues: {
+		Category:    CategoryThrottling,
+		Title:       "Rate limit issues",
+		Description: "The WhatsApp Business Account has reached its rate limit.",
+		Retryable:   true,
+		Action:      "Reduce the frequency or amount of calls and retry later.",
+	},
+	130472: {
+		Category:    CategoryRecipient,
+		Title:       "User's number is part of an experiment",
+		Description: "The message was not sent as part of an experiment.",
+		Action:      "See the marketing message experiment documentation.",
+	},
+	CodeCloudAPIThroughput: {
+		Category:    CategoryThrottling,
+		Title:       "Rate limit hit",
+		Description: "Cloud API message throughput has been reached.",
+		Retryable:   true,
+		Action:      "Retry later, or ask for a higher throughput.",
+	},
+	130497: {
+		Category:    CategoryBusiness,
+		Title:       "Business account is restricted from messaging users in this country",
+		Description: "The business cannot send messages to users in the country of the recipient.",
+		Action:      "See the availability of the business in the country.",
+	},
+	CodeSomethingWentWrong: {
+		Category:    CategoryServer,
+		Title:       "Something went wrong",
+		Description: "The message failed to send due to an unknown error.",
+		Retryable:   true,
+		Action:      "Retry later, contact support if the error persists.",
+	},
+	131005: {
+		Category:    CategoryAuthorization,
+		Title:       "Access denied",
+		Description: "Permission is either not granted or has been removed.",
+		Action:      "Check that the app has the required permissions.",
+	},
+	131008: {
+		Category:    CategoryParameter,
+		Title:       "Required parameter is missing",
+		Description: "The request is missing a required parameter.",
+		Action:      "Check the endpoint reference for the required parameters.",
+	},
+	131009: {
+		Category:    CategoryParameter,
+		Title:       "Parameter value is not valid",
+		Description: "One or more parameter values are invalid.",
+		Action:      "Check the endpoint reference for the supported values.",
+	},
+	CodeServiceUnavailable: {
+		Category:    CategoryServer,
+		Title:       "Service unavailable",
+		Description: "A service is temporarily unavailable.",
+		Retryable:   true,
+		Action:      "Check the WhatsApp Business Platform status page and retry later.",
+	},
+	131021: {
+		Category:    CategoryRecipient,
+		Title:       "Recipient cannot be sender",
+		Description: "The sender and the recipient phone numbers are the same.",
+		Action:      "Send the message to a phone number other than the sender.",
+	},
+	CodeMessageUndeliverable: {
+		Category:    CategoryRecipient,
+		Title:       "Message undeliverable",
+		Description: "Unable to deliver the message, for example the recipient is not a WhatsApp user.",
+		Action:      "Check that the recipient uses WhatsApp and has accepted the latest terms.",
+	},
+	CodeRecipientNotAllowed: {
+		Category:    CategoryRecipient,
+		Title:       "Recipient phone number not in allowed list",
+		Description: "The test phone number can only message the numbers in its allowed list.",
+		Action:      "Add the recipient phone number to the allowed list.",
+	},
+	CodeAccountLocked: {
+		Category:    CategoryIntegrity,
+		Title:       "Account has been locked",
+		Description: "The account has been locked and cannot send messages due to an integrity policy violation.",
+		Action:      "See the policy enforcement documentation.",
+	},
+	131037: {
+		Category:    CategoryBusiness,
+		Title:       "WhatsApp provided number needs display name approval before message can be sent",
+		Description: "The display name of the business phone number has not been approved.",
+		Action:      "Get the display name approved.",
+	},
+	CodeBusinessEligibility: {
+		Category:    CategoryBusiness,
+		Title:       "Business eligibility payment issue",
+		Description: "There was an error related to the payment method of the business.",
+		Action:      "Check the payment method of the WhatsApp Business Account.",
+	},
+	131045: {
+		Category:    CategoryRegistration,
+		Title:       "Incorrect certificate",
+		Description: "The phone number is not registered.",
+		Action:      "Register the phone number before sending messages.",
+	},
+	CodeReengagementMessage: {
+		Category:    CategoryReengagement,
+		Title:       "Re-engagement message",
+		Description: "More than 24 hours have passed since the recipient last replied to the sender number.",
+		Action:      "Send a template message instead.",
+	},
+	131051: {
+		Category:    CategoryParameter,
+		Title:       "Unsupported message type",
+		Description: "The message type is not supported.",
+		Action:      "Use a supported message type.",
+	},
+	CodeMediaDownloadError: {
+		Category:    CategoryMedia,
+		Title:       "Media download error",
+		Description: "Unable to download the media sent by the user.",
+		Action:      "Ask the user to send the media again by other means.",
+	},
+	CodeMediaUploadError: {
+		Category:    CategoryMedia,
+		Title:       "Media upload error",
+		Description: "Unable to upload the media used in the message.",
+		Action:      "Check that the media type is supported and that the media URL is reachable.",
+	},
+	CodePairRateLimitHit: {
+		Category:    CategoryThrottling,
+		Title:       "Pair rate limit hit",
+		Description: "Too many messages sent from the sender phone number to the same recipient in a short period.",
+		Retryable:   true,
+		Action:      "Wait and retry sending the message to the same recipient.",
+	},
+	CodeAccountInMaintenance: {
+		Category:    CategoryServer,
+		Title:       "Account in maintenance mode",
+		Description: "The business account is in maintenance mode, for example during a throughput upgrade.",
+		Retryable:   true,
+		Action:      "Retry in a few minutes.",
+	},
+	CodeTemplateParamMismatch: {
+		Category:    CategoryTemplate,
+		Title:       "Template param count mismatch",
+		Description: "The number of parameters does not match the number expected by the template.",
+		Action:      "Send the parameters defined by the template.",
+	},
+	CodeTemplateDoesNotExist: {
+		Category:    CategoryTemplate,
+		Title:       "Template does not exist",
+		Description: "The template does not exist in the given language or has not been approved.",
+		Action:      "Check the name, language and status of the template.",
+	},
+	CodeTemplateHydratedTooLong: {
+		Category:    CategoryTemplate,
+		Title:       "Template hydrated text too long",
+		Description: "The translated text is too long.",
+		Action:      "Shorten the parameters of the template.",
+	},
+	132007: {
+		Category:    CategoryTemplate,
+		Title:       "Template format character policy violated",
+		Description: "The template content violates a WhatsApp policy.",
+		Action:      "See the template policies.",
+	},
+	132012: {
+		Category:    CategoryTemplate,
+		Title:       "Template parameter format mismatch",
+		Description: "The parameters do not match the format of the template.",
+		Action:      "Send parameters in the format defined by the template.",
+	},
+	132015: {
+		Category:    CategoryTemplate,
+		Title:       "Template is paused",
+		Description: "The template is paused due to low quality.",
+		Action:      "Edit the template to improve its quality.",
+	},
+	CodeTemplateDisabled: {
+		Category:    CategoryTemplate,
+		Title:       "Template is disabled",
+		Description: "The template has been paused too many times due to low quality and is now disabled.",
+		Action:      "Create a new template with different content.",
+	},
+	132068: {
+		Category:    CategoryFlow,
+		Title:       "Flow is in blocked state",
+		Description: "The flow is in a blocked state.",
+		Action:      "Fix the flow.",
+	},
+	132069: {
+		Category:    CategoryFlow,
+		Title:       "Flow is in throttled state",
+		Description: "The flow is throttled and 10 messages using it were already sent in the last hour.",
+		Retryable:   true,
+		Action:      "Fix the flow and retry later.",
+	},
+	133000: {
+		Category:    CategoryRegistration,
+		Title:       "Incomplete deregistration",
+		Description: "A previous deregistration attempt failed.",
+		Action:      "Deregister the phone number again before registering it.",
+	},
+	CodeServerUnavailable: {
+		Category:    CategoryServer,
+		Title:       "Server temporarily unavailable",
+		Description: "The server is temporarily unavailable.",
+		Retryable:   true,
+		Action:      "Check the WhatsApp Business Platform status page and retry later.",
+	},
+	133005: {
+		Category:    CategoryRegistration,
+		Title:       "Two step verification PIN mismatch",
+		Description: "The two step verification PIN is incorrect.",
+		Action:      "Check the PIN, or reset it.",
+	},
+	133006: {
+		Category:    CategoryRegistration,
+		Title:       "Phone number re-verification needed",
+		Description: "The phone number must be verified before it is registered.",
+		Action:      "Verify the phone number before registering it.",
+	},
+	133008: {
+		Category:    CategoryRegistration,
+		Title:       "Too many two step verification PIN guesses",
+		Description: "Too many incorrect PINs were entered for this phone number.",
+		Retryable:   true,
+		Action:      "Retry after the amount of time given in the details.",
+	},
+	133009: {
+		Category:    CategoryRegistration,
+		Title:       "Two step verification PIN guessed too fast",
+		Description: "The PIN was entered too quickly.",
+		Retryable:   true,
+		Action:      "Retry after the amount of time given in the details.",
+	},
+	CodePhoneNumberNotVerified: {
+		Category:    CategoryRegistration,
+		Title:       "Phone number not registered",
+		Description: "The phone number is not registered on the WhatsApp Business Platform.",
+		Action:      "Register the phone number.",
+	},
+	CodeRetryAfterFewMinutes: {
+		Category:    CategoryRegistration,
+		Title:       "Please wait a few minutes before attempting to register this phone number",
+		Description: "The phone number was recently deleted and the deletion is not complete.",
+		Retryable:   true,
+		Action:      "Wait 5 minutes before retrying.",
+	},
+	135000: {
+		Category:    CategoryParameter,
+		Title:       "Generic user error",
+		Description: "The message failed to send because of an unknown error with the request parameters.",
+		Action:      "Check the endpoint reference, contact support if the error persists.",
+	},
+}
+
+// Lookup returns the description of an error code. It returns false for codes that are not
+// in the Catalogue, with a CodeInfo of CategoryUnknown.
+func Lookup(code int) (CodeInfo, bool) {
+	if info, ok := Catalogue[code]; ok {
+		info.Code = code
+
+		return info, true
+	}
+
+	if code >= permissionCodeRangeStart && code <= permissionCodeRangeEnd {
+		return CodeInfo{
+			Code:        code,
+			Category:    CategoryAuthorization,
+			Title:       "API Permission",
+			Description: "Permission is either not granted or has been removed.",
+			Action:      "Check that the app has the required permissions.",
+		}, true
+	}
+
+	if code >= templateCodeRangeStart && code <= templateCodeRangeEnd {
+		return CodeInfo{
+			Code:        code,
+			Category:    CategoryTemplate,
+			Title:       "Template error",
+			Description: "The template could not be used.",
+			Action:      "Check the template and its parameters.",
+		}, true
+	}
+
+	return CodeInfo{Code: code, Category: CategoryUnknown}, false
+}
+
+// Info returns the description of the code of the error.
+func (e *Error) Info() CodeInfo {
+	info, _ := Lookup(e.Code)
+
+	return info
+}
+
+// Classify returns the description of the code of the first *Error in the chain of err,
+// including the ones wrapped by whttp.ResponseError. It returns false when there is none.
+func Classify(err error) (CodeInfo, bool) {
+	var e *Error
+	if !errors.As(err, &e) || e == nil {
+		return CodeInfo{Category: CategoryUnknown}, false
+	}
+
+	return e.Info(), true
+}
+
+// CategoryOf returns the category of the error, CategoryUnknown if it is not a WhatsApp error.
+func CategoryOf(err error) Category {
+	info, _ := Classify(err)
+
+	return info.Category
+}
+
+// IsRateLimited reports whether the request was throttled.
+func IsRateLimited(err error) bool {
+	return CategoryOf(err) == CategoryThrottling
+}
+
+// IsReengagementRequired reports whether the customer service window is closed, in which
+// case only template messages can be sent.
+func IsReengagementRequired(err error) bool {
+	return CategoryOf(err) == CategoryReengagement
+}
+
+// IsTemplateError reports whether the template, or its parameters, are not valid.
+func IsTemplateError(err error) bool {
+	return CategoryOf(err) == CategoryTemplate
+}
+
+// IsAuthorizationError reports whether the access token is not valid or lacks permissions.
+func IsAuthorizationError(err error) bool {
+	return CategoryOf(err) == CategoryAuthorization
+}
+
+// IsIntegrityError reports whether the account is restricted for policy violations.
+func IsIntegrityError(err error) bool {
+	return CategoryOf(err) == CategoryIntegrity
+}
+
+// IsRecipientError reports whether the message cannot be delivered to the recipient.
+func IsRecipientError(err error) bool {
+	return CategoryOf(err) == CategoryRecipient
+}
+
+// IsMediaError reports whether media could not be uploaded or downloaded.
+func IsMediaError(err error) bool {
+	return CategoryOf(err) == CategoryMedia
+}
+
+// IsRetryable reports whether the request may succeed later without changes.
+func IsRetryable(err error) bool {
+	info, ok := Classify(err)
+
+	return ok && info.Retryable
+}
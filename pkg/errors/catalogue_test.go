@@ -0,0 +1,124 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		err       error
+		category  Category
+		retryable bool
+		predicate func(error) bool
+	}{
+		{
+			name:      "throughput",
+			err:       &Error{Code: CodeCloudAPIThroughput},
+			category:  CategoryThrottling,
+			retryable: true,
+			predicate: IsRateLimited,
+		},
+		{
+			name:      "wrapped pair rate limit",
+			err:       fmt.Errorf("send text: %w", &Error{Code: CodePairRateLimitHit}),
+			category:  CategoryThrottling,
+			retryable: true,
+			predicate: IsRateLimited,
+		},
+		{
+			name:      "re-engagement",
+			err:       &Error{Code: CodeReengagementMessage},
+			category:  CategoryReengagement,
+			predicate: IsReengagementRequired,
+		},
+		{
+			name:      "template range",
+			err:       &Error{Code: 132010},
+			category:  CategoryTemplate,
+			predicate: IsTemplateError,
+		},
+		{
+			name:      "permission range",
+			err:       &Error{Code: 230},
+			category:  CategoryAuthorization,
+			predicate: IsAuthorizationError,
+		},
+		{
+			name:      "locked account",
+			err:       &Error{Code: CodeAccountLocked},
+			category:  CategoryIntegrity,
+			predicate: IsIntegrityError,
+		},
+		{
+			name:      "service unavailable",
+			err:       &Error{Code: CodeAPIService},
+			category:  CategoryServer,
+			retryable: true,
+			predicate: IsRetryable,
+		},
+		{
+			name:     "unknown code",
+			err:      &Error{Code: 999999},
+			category: CategoryUnknown,
+		},
+		{
+			name:     "not a whatsapp error",
+			err:      errTest,
+			category: CategoryUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := CategoryOf(tt.err); got != tt.category {
+				t.Errorf("CategoryOf() = %s, want %s", got, tt.category)
+			}
+
+			if got := IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.retryable)
+			}
+
+			if tt.predicate != nil && !tt.predicate(tt.err) {
+				t.Errorf("predicate returned false")
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+	info, ok := Lookup(CodeMediaUploadError)
+	if !ok || info.Code != CodeMediaUploadError || info.Category != CategoryMedia || info.Action == "" {
+		t.Errorf("Lookup() = %+v, %v", info, ok)
+	}
+
+	for code, info := range Catalogue {
+		if info.Category == "" || info.Title == "" || info.Action == "" {
+			t.Errorf("code %d is not fully described", code)
+		}
+	}
+}
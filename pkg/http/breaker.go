@@ -0,0 +1,417 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	werrors "github.com/piusalfred/whatsapp/pkg/errors"
+)
+
+// CircuitState is the state of a circuit of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects every call with a *CircuitOpenError.
+	CircuitOpen
+
+	// CircuitHalfOpen lets a few trial calls through to find out whether the API recovered.
+	CircuitHalfOpen
+)
+
+// Defaults of a CircuitBreaker.
+const (
+	DefaultCircuitConsecutiveFailures = 5
+	DefaultCircuitFailureRate         = 0.5
+	DefaultCircuitMinRequests         = 20
+	DefaultCircuitWindow              = time.Minute
+	DefaultCircuitOpenTimeout         = 30 * time.Second
+	DefaultCircuitHalfOpenRequests    = 1
+)
+
+// ErrCircuitOpen is matched by the *CircuitOpenError returned when a call is rejected.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// String returns the name of the state.
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(state))
+	}
+}
+
+type (
+	// CircuitOpenError is returned instead of making a call while its circuit is open.
+	// RetryAt is when the circuit lets a trial call through again, callers can queue the
+	// message until then.
+	CircuitOpenError struct {
+		Key     string
+		RetryAt time.Time
+	}
+
+	// CircuitStateFunc is called when the circuit of key changes state.
+	CircuitStateFunc func(key string, from, to CircuitState)
+
+	// CircuitBreakerOption configures a CircuitBreaker.
+	CircuitBreakerOption func(breaker *CircuitBreaker)
+
+	// CircuitBreaker stops calling the API when it keeps failing. Calls are grouped in
+	// circuits, usually one per route and phone number, see CircuitKey. A circuit opens
+	// after a number of consecutive failures or when the failure rate within a window is
+	// too high, rejects calls for the open timeout and then lets trial calls through. Only
+	// infrastructure failures count, see IsInfrastructureFailure, a rejected payload does
+	// not say anything about the health of the API.
+	CircuitBreaker struct {
+		mu                  sync.Mutex
+		circuits            map[string]*circuit
+		consecutiveFailures int
+		failureRate         float64
+		minRequests         int
+		window              time.Duration
+		openTimeout         time.Duration
+		halfOpenRequests    int
+		isFailure           func(err error) bool
+		onStateChange       CircuitStateFunc
+		now                 func() time.Time
+	}
+
+	circuit struct {
+		state       CircuitState
+		consecutive int
+		requests    int
+		failures    int
+		windowStart time.Time
+		openedAt    time.Time
+		inFlight    int
+		successes   int
+	}
+)
+
+// Error returns the error message for CircuitOpenError.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open for %s, retry at %s", e.Key, e.RetryAt.Format(time.RFC3339))
+}
+
+// Unwrap returns ErrCircuitOpen.
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// WithCircuitConsecutiveFailures sets the number of consecutive failures that open a
+// circuit. Zero disables the check.
+func WithCircuitConsecutiveFailures(failures int) CircuitBreakerOption {
+	return func(breaker *CircuitBreaker) {
+		breaker.consecutiveFailures = failures
+	}
+}
+
+// WithCircuitFailureRate sets the rate of failures, between 0 and 1, that opens a circuit
+// once it had at least minRequests calls within the window. A zero rate disables the check.
+func WithCircuitFailureRate(rate float64, minRequests int, window time.Duration) CircuitBreakerOption {
+	return func(breaker *CircuitBreaker) {
+		breaker.failureRate = rate
+		breaker.minRequests = minRequests
+		breaker.window = window
+	}
+}
+
+// WithCircuitOpenTimeout sets how long a circuit stays open before trial calls are let
+// through, and how many trial calls must succeed for it to close.
+func WithCircuitOpenTimeout(timeout time.Duration, halfOpenRequests int) CircuitBreakerOption {
+	return func(breaker *CircuitBreaker) {
+		breaker.openTimeout = timeout
+		breaker.halfOpenRequests = halfOpenRequests
+	}
+}
+
+// WithCircuitFailureFunc replaces IsInfrastructureFailure as the function that decides
+// which errors count as failures.
+func WithCircuitFailureFunc(isFailure func(err error) bool) CircuitBreakerOption {
+	return func(breaker *CircuitBreaker) {
+		breaker.isFailure = isFailure
+	}
+}
+
+// WithCircuitStateChange sets a function called when a circuit changes state. It is called
+// synchronously and must not block.
+func WithCircuitStateChange(fn CircuitStateFunc) CircuitBreakerOption {
+	return func(breaker *CircuitBreaker) {
+		breaker.onStateChange = fn
+	}
+}
+
+// NewCircuitBreaker returns a CircuitBreaker with the default thresholds changed by opts.
+func NewCircuitBreaker(opts ...CircuitBreakerOption) *CircuitBreaker {
+	breaker := &CircuitBreaker{
+		circuits:            map[string]*circuit{},
+		consecutiveFailures: DefaultCircuitConsecutiveFailures,
+		failureRate:         DefaultCircuitFailureRate,
+		minRequests:         DefaultCircuitMinRequests,
+		window:              DefaultCircuitWindow,
+		openTimeout:         DefaultCircuitOpenTimeout,
+		halfOpenRequests:    DefaultCircuitHalfOpenRequests,
+		isFailure:           IsInfrastructureFailure,
+		now:                 time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(breaker)
+		}
+	}
+
+	breaker.halfOpenRequests = max(breaker.halfOpenRequests, 1)
+
+	return breaker
+}
+
+// WithCircuitBreaker makes the client check breaker before every request, using the
+// CircuitKey of the request.
+func WithCircuitBreaker(breaker *CircuitBreaker) ClientOption {
+	return func(client *Client) {
+		client.breaker = breaker
+	}
+}
+
+// CircuitKey returns the circuit of a request: the node and edges of its route, or its
+// endpoints, and the phone number or WhatsApp Business Account that owns it. The ids of
+// leaf objects, such as a media ID or a QR code, are left out so that a circuit is not
+// opened per object.
+func CircuitKey(ctx *RequestContext) string {
+	if ctx == nil {
+		return ""
+	}
+
+	if ctx.Route == nil {
+		return strings.Join(ctx.Endpoints, "/") + "@" + ctx.PhoneNumberID
+	}
+
+	route := string(ctx.Route.Node)
+	edges := ctx.Route.Edges
+	if ctx.Route.Node == NodeQRCode && len(edges) > 1 {
+		// the edge after message_qrdls is the code of a single QR code
+		edges = edges[:1]
+	}
+
+	if len(edges) > 0 {
+		route += "/" + strings.Join(edges, "/")
+	}
+
+	return route + "@" + circuitOwner(ctx)
+}
+
+// circuitOwner returns the id of the phone number, WhatsApp Business Account, app or
+// business that owns the object targeted by the request.
+func circuitOwner(ctx *RequestContext) string {
+	switch ctx.Route.Node {
+	case NodePhoneNumber, NodeBusinessAccount, NodeQRCode, NodeApp, NodeBusiness:
+		if ctx.Route.ID != "" {
+			return ctx.Route.ID
+		}
+	}
+
+	if ctx.PhoneNumberID != "" {
+		return ctx.PhoneNumberID
+	}
+
+	return ctx.BusinessAccountID
+}
+
+// IsInfrastructureFailure reports whether err says that the API is unhealthy: a 5xx
+// response, a server error code of the API such as 1 or 2, a timeout or a failed
+// connection. Canceled calls and errors about the request itself are not failures.
+func IsInfrastructureFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var responseErr *ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.Code >= http.StatusInternalServerError ||
+			werrors.CategoryOf(responseErr) == werrors.CategoryServer
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
+
+// Allow asks whether a call of the circuit key may be made. When it may, done must be
+// called with the result of the call. Otherwise the error is a *CircuitOpenError.
+func (breaker *CircuitBreaker) Allow(key string) (func(err error), error) {
+	breaker.mu.Lock()
+	now := breaker.now()
+	c := breaker.circuit(key)
+	from := c.state
+
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= breaker.openTimeout {
+		c.state, c.inFlight, c.successes = CircuitHalfOpen, 0, 0
+	}
+
+	var err error
+	switch {
+	case c.state == CircuitOpen:
+		err = &CircuitOpenError{Key: key, RetryAt: c.openedAt.Add(breaker.openTimeout)}
+	case c.state == CircuitHalfOpen && c.inFlight >= breaker.halfOpenRequests:
+		err = &CircuitOpenError{Key: key, RetryAt: now.Add(breaker.openTimeout)}
+	case c.state == CircuitHalfOpen:
+		c.inFlight++
+	}
+	to := c.state
+	breaker.mu.Unlock()
+
+	breaker.notify(key, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+
+	return func(err error) {
+		once.Do(func() { breaker.done(key, err) })
+	}, nil
+}
+
+// Execute calls fn if the circuit key allows it and records its result.
+func (breaker *CircuitBreaker) Execute(key string, fn func() error) error {
+	done, err := breaker.Allow(key)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	done(err)
+
+	return err
+}
+
+// State returns the state of the circuit key.
+func (breaker *CircuitBreaker) State(key string) CircuitState {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	c, ok := breaker.circuits[key]
+	if !ok {
+		return CircuitClosed
+	}
+
+	if c.state == CircuitOpen && breaker.now().Sub(c.openedAt) >= breaker.openTimeout {
+		return CircuitHalfOpen
+	}
+
+	return c.state
+}
+
+// Reset closes the circuit key.
+func (breaker *CircuitBreaker) Reset(key string) {
+	breaker.mu.Lock()
+	from := CircuitClosed
+	if c, ok := breaker.circuits[key]; ok {
+		from = c.state
+		delete(breaker.circuits, key)
+	}
+	breaker.mu.Unlock()
+
+	breaker.notify(key, from, CircuitClosed)
+}
+
+func (breaker *CircuitBreaker) done(key string, err error) {
+	failed := breaker.isFailure(err)
+
+	breaker.mu.Lock()
+	now := breaker.now()
+	c := breaker.circuit(key)
+	from := c.state
+
+	switch c.state {
+	case CircuitHalfOpen:
+		c.inFlight = max(c.inFlight-1, 0)
+		if failed {
+			breaker.open(c, now)
+		} else if c.successes++; c.successes >= breaker.halfOpenRequests {
+			*c = circuit{state: CircuitClosed, windowStart: now}
+		}
+	case CircuitClosed:
+		if breaker.window > 0 && now.Sub(c.windowStart) >= breaker.window {
+			c.requests, c.failures, c.windowStart = 0, 0, now
+		}
+
+		c.requests++
+		if !failed {
+			c.consecutive = 0
+
+			break
+		}
+
+		c.consecutive++
+		c.failures++
+		tooMany := breaker.consecutiveFailures > 0 && c.consecutive >= breaker.consecutiveFailures
+		tooOften := breaker.failureRate > 0 && c.requests >= breaker.minRequests &&
+			float64(c.failures)/float64(c.requests) >= breaker.failureRate
+		if tooMany || tooOften {
+			breaker.open(c, now)
+		}
+	case CircuitOpen:
+		// a call that started before the circuit opened
+	}
+	to := c.state
+	breaker.mu.Unlock()
+
+	breaker.notify(key, from, to)
+}
+
+func (breaker *CircuitBreaker) open(c *circuit, now time.Time) {
+	*c = circuit{state: CircuitOpen, openedAt: now, windowStart: now}
+}
+
+func (breaker *CircuitBreaker) circuit(key string) *circuit {
+	c, ok := breaker.circuits[key]
+	if !ok {
+		c = &circuit{state: CircuitClosed, windowStart: breaker.now()}
+		breaker.circuits[key] = c
+	}
+
+	return c
+}
+
+func (breaker *CircuitBreaker) notify(key string, from, to CircuitState) {
+	if from != to && breaker.onStateChange != nil {
+		breaker.onStateChange(key, from, to)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	werrors "github.com/piusalfred/whatsapp/pkg/errors"
+)
+
+func TestIsInfrastructureFailure(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "server error", err: &ResponseError{Code: http.StatusBadGateway}, want: true},
+		{name: "api service", err: &ResponseError{Code: 400, Err: &werrors.Error{Code: 2}}, want: true},
+		{name: "invalid parameter", err: &ResponseError{Code: 400, Err: &werrors.Error{Code: 100}}, want: false},
+		{name: "timeout", err: fmt.Errorf("send: %w", context.DeadlineExceeded), want: true},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "other", err: errors.New("encode payload"), want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsInfrastructureFailure(tt.err); got != tt.want {
+				t.Errorf("IsInfrastructureFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var transitions []string
+	breaker := NewCircuitBreaker(
+		WithCircuitConsecutiveFailures(3),
+		WithCircuitFailureRate(0, 0, 0),
+		WithCircuitOpenTimeout(time.Minute, 1),
+		WithCircuitStateChange(func(key string, from, to CircuitState) {
+			transitions = append(transitions, fmt.Sprintf("%s:%s->%s", key, from, to))
+		}),
+	)
+	breaker.now = func() time.Time { return now }
+
+	failure := &ResponseError{Code: http.StatusServiceUnavailable}
+	invalid := &ResponseError{Code: http.StatusBadRequest, Err: &werrors.Error{Code: 100}}
+
+	// validation errors do not count
+	for i := 0; i < 5; i++ {
+		_ = breaker.Execute("a", func() error { return invalid })
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = breaker.Execute("a", func() error { return failure })
+	}
+
+	if breaker.State("a") != CircuitOpen || breaker.State("b") != CircuitClosed {
+		t.Fatalf("states = %s and %s, want open and closed", breaker.State("a"), breaker.State("b"))
+	}
+
+	var openErr *CircuitOpenError
+	err := breaker.Execute("a", func() error { return nil })
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || !openErr.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected a CircuitOpenError, got %v", err)
+	}
+
+	// a failed trial call opens the circuit again, a successful one closes it
+	now = now.Add(time.Minute)
+	_ = breaker.Execute("a", func() error { return failure })
+	now = now.Add(time.Minute)
+	done, err := breaker.Allow("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = breaker.Allow("a"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected only one trial call, got %v", err)
+	}
+	done(nil)
+
+	want := "[a:closed->open a:open->half-open a:half-open->open a:open->half-open a:half-open->closed]"
+	if fmt.Sprint(transitions) != want {
+		t.Errorf("transitions = %v, want %s", transitions, want)
+	}
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(WithCircuitConsecutiveFailures(0), WithCircuitFailureRate(0.5, 4, time.Minute))
+	results := []error{nil, context.DeadlineExceeded, nil, context.DeadlineExceeded}
+	for _, result := range results {
+		result := result
+		_ = breaker.Execute("a", func() error { return result })
+	}
+
+	if breaker.State("a") != CircuitOpen {
+		t.Errorf("state = %s, want open", breaker.State("a"))
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCircuitBreaker(NewCircuitBreaker(WithCircuitConsecutiveFailures(2))))
+	request := &Request{
+		Context: &RequestContext{
+			BaseURL:       server.URL,
+			ApiVersion:    "v16.0",
+			PhoneNumberID: "1234",
+			Route:         PhoneNumberRoute("1234", "messages"),
+		},
+		Method: http.MethodPost,
+	}
+
+	var out map[string]any
+	for i := 0; i < 3; i++ {
+		err := client.Do(context.TODO(), request, &out)
+		if i == 2 && !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected ErrCircuitOpen, got %v", err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+
+	if key := CircuitKey(request.Context); key != "phone_number/messages@1234" {
+		t.Errorf("CircuitKey() = %q", key)
+	}
+}
+
+func TestCircuitKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		ctx  *RequestContext
+		want string
+	}{
+		{name: "nil", want: ""},
+		{
+			name: "phone number",
+			ctx:  &RequestContext{PhoneNumberID: "1234", Route: PhoneNumberRoute("1234", "messages")},
+			want: "phone_number/messages@1234",
+		},
+		{
+			name: "other phone number",
+			ctx:  &RequestContext{PhoneNumberID: "1234", Route: PhoneNumberRoute("5678", "messages")},
+			want: "phone_number/messages@5678",
+		},
+		{
+			name: "business account",
+			ctx:  &RequestContext{Route: BusinessAccountRoute("waba", "message_templates")},
+			want: "whatsapp_business_account/message_templates@waba",
+		},
+		{
+			name: "single qr code",
+			ctx:  &RequestContext{PhoneNumberID: "1234", Route: QRCodeRoute("1234", "CODE")},
+			want: "qr_code/message_qrdls@1234",
+		},
+		{
+			name: "media",
+			ctx:  &RequestContext{PhoneNumberID: "1234", Route: MediaRoute("media-id")},
+			want: "media@1234",
+		},
+		{
+			name: "template",
+			ctx:  &RequestContext{BusinessAccountID: "waba", Route: TemplateRoute("template-id")},
+			want: "message_template@waba",
+		},
+		{
+			name: "endpoints",
+			ctx:  &RequestContext{PhoneNumberID: "1234", Endpoints: []string{"messages"}},
+			want: "messages@1234",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := CircuitKey(tt.ctx); got != tt.want {
+				t.Errorf("CircuitKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
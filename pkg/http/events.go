@@ -0,0 +1,162 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// EventKind is the kind of an Event.
+type EventKind string
+
+const (
+	// EventBodyCloseFailed is reported when a response body cannot be closed.
+	EventBodyCloseFailed EventKind = "body_close_failed"
+
+	// EventRequestHookFailed is reported when a RequestHook returns an error.
+	EventRequestHookFailed EventKind = "request_hook_failed"
+
+	// EventResponseHookFailed is reported when a ResponseHook returns an error.
+	EventResponseHookFailed EventKind = "response_hook_failed"
+
+	// EventRetry is reported before a failed call is retried.
+	EventRetry EventKind = "retry"
+)
+
+var _ slog.LogValuer = (*Event)(nil)
+
+type (
+	// Event is something that happened while making a call that is not returned to the
+	// caller, or not only, such as a failure to close a response body or a retry.
+	Event struct {
+		Kind        EventKind
+		RequestName string
+		Err         error
+		Attempt     int
+		Time        time.Time
+	}
+
+	// EventHandler receives the events of a Client. It is called synchronously by the
+	// goroutine making the call, so it must not block.
+	EventHandler func(ctx context.Context, event *Event)
+)
+
+// LogValue returns the event as a group of attributes.
+func (event *Event) LogValue() slog.Value {
+	if event == nil {
+		return slog.StringValue("nil")
+	}
+
+	attrs := []slog.Attr{
+		slog.String("kind", string(event.Kind)),
+		slog.String("request", event.RequestName),
+	}
+	if event.Attempt > 0 {
+		attrs = append(attrs, slog.Int("attempt", event.Attempt))
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// WithEventHandler adds handlers that receive every event of the client.
+func WithEventHandler(handlers ...EventHandler) ClientOption {
+	return func(client *Client) {
+		client.events = append(client.events, handlers...)
+	}
+}
+
+// WithErrorHandler adds a handler that receives the error of every event that has one.
+func WithErrorHandler(handler func(err error)) ClientOption {
+	return WithEventHandler(func(_ context.Context, event *Event) {
+		if event.Err != nil {
+			handler(event.Err)
+		}
+	})
+}
+
+// SlogEventHandler returns an EventHandler that logs events to logger, failures at the
+// warning level and retries at the info level.
+func SlogEventHandler(logger *slog.Logger) EventHandler {
+	return func(ctx context.Context, event *Event) {
+		level := slog.LevelWarn
+		if event.Kind == EventRetry {
+			level = slog.LevelInfo
+		}
+
+		logger.LogAttrs(ctx, level, "whatsapp http event", slog.Any("event", event))
+	}
+}
+
+// Report sends event to the event handlers of the client and, if it has an error, to
+// ListenErrors. It never blocks on ListenErrors: the error is dropped when nobody reads
+// them fast enough.
+func (client *Client) Report(ctx context.Context, event *Event) {
+	if event == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	client.logEvent(ctx, event)
+
+	for _, handler := range client.events {
+		if handler != nil {
+			handler(ctx, event)
+		}
+	}
+
+	if event.Err == nil {
+		return
+	}
+
+	client.errorsMu.RLock()
+	defer client.errorsMu.RUnlock()
+	if client.closed {
+		client.dropped.Add(1)
+
+		return
+	}
+
+	select {
+	case client.errorChannel <- event.Err:
+	default:
+		client.dropped.Add(1)
+	}
+}
+
+// DroppedErrors returns the number of errors that were not passed to ListenErrors.
+func (client *Client) DroppedErrors() int64 {
+	return client.dropped.Load()
+}
+
+func requestName(r *Request) string {
+	if r == nil || r.Context == nil {
+		return ""
+	}
+
+	return r.Context.Name
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var errClose = errors.New("close failed")
+
+type failingBody struct {
+	io.Reader
+}
+
+func (failingBody) Close() error {
+	return errClose
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return fn(request)
+}
+
+func TestClientEvents(t *testing.T) {
+	t.Parallel()
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       failingBody{Reader: strings.NewReader(`{}`)},
+			Request:    request,
+		}, nil
+	})
+
+	var events []*Event
+	client := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithEventHandler(func(_ context.Context, event *Event) { events = append(events, event) }),
+	)
+
+	request := &Request{
+		Context: &RequestContext{Name: "send message", BaseURL: "https://example.com", Route: RootRoute("x")},
+		Method:  http.MethodGet,
+	}
+
+	// nobody listens to the errors, the call must not block
+	var out map[string]any
+	for i := 0; i < DefaultErrorBufferSize+1; i++ {
+		if err := client.Do(context.TODO(), request, &out); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(events) != DefaultErrorBufferSize+1 || events[0].Kind != EventBodyCloseFailed ||
+		events[0].RequestName != "send message" || !errors.Is(events[0].Err, errClose) {
+		t.Fatalf("unexpected events %v", events)
+	}
+
+	if client.DroppedErrors() != 1 {
+		t.Errorf("DroppedErrors() = %d, want 1", client.DroppedErrors())
+	}
+
+	hookErr := errors.New("hook failed")
+	client.AppendRequestHooks(func(context.Context, *http.Request) error { return hookErr })
+	if err := client.Do(context.TODO(), request, &out); !errors.Is(err, hookErr) {
+		t.Errorf("expected the hook error, got %v", err)
+	}
+
+	if last := events[len(events)-1]; last.Kind != EventRequestHookFailed {
+		t.Errorf("last event = %s, want %s", last.Kind, EventRequestHookFailed)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reporting after close does not panic
+	client.Report(context.TODO(), &Event{Kind: EventRetry, Err: hookErr})
+
+	received := 0
+	client.ListenErrors(func(error) { received++ })
+	if received != DefaultErrorBufferSize {
+		t.Errorf("received %d errors, want %d", received, DefaultErrorBufferSize)
+	}
+}
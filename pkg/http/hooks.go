@@ -22,10 +22,16 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"strings"
+
+	"github.com/piusalfred/whatsapp/pkg/redact"
 )
 
 type (
@@ -33,55 +39,131 @@ type (
 	ResponseHook func(ctx context.Context, response *http.Response) error
 )
 
-func LogRequestHook(logger *slog.Logger) RequestHook {
+// AppSecretProofParam is the query parameter that carries the appsecret_proof.
+const AppSecretProofParam = "appsecret_proof"
+
+// AppSecretProof returns the appsecret_proof of the access token: the hex encoded
+// HMAC-SHA256 of the token keyed by the app secret.
+func AppSecretProof(secret, token string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AppSecretProofHook returns a RequestHook that adds the appsecret_proof of the access token
+// to every request, as required by apps with "Require App Secret" turned on. The token is
+// taken from the bearer Authorization header or from the access_token query parameter.
+// Requests without a token are left untouched.
+func AppSecretProofHook(secret string) RequestHook {
+	return AppSecretProofHookFunc(func(context.Context) string { return secret })
+}
+
+// AppSecretProofHookFunc is like AppSecretProofHook but looks the app secret up for every
+// request, so that it can change over time. An empty secret adds no proof.
+func AppSecretProofHookFunc(secret func(ctx context.Context) string) RequestHook {
 	return func(ctx context.Context, request *http.Request) error {
-		name := RequestNameFromContext(ctx)
-		reader, err := request.GetBody()
-		if err != nil {
-			return fmt.Errorf("log request hook: %w", err)
+		key := secret(ctx)
+		if key == "" {
+			return nil
 		}
-		buf := new(bytes.Buffer)
-		if _, err = buf.ReadFrom(reader); err != nil {
-			return fmt.Errorf("log request hook: %w", err)
+
+		query := request.URL.Query()
+		token := query.Get("access_token")
+		if bearer, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer "); ok && bearer != "" {
+			token = bearer
 		}
 
-		hb := &strings.Builder{}
-		hb.WriteString("[")
-		for k, v := range request.Header {
-			hb.WriteString(fmt.Sprintf("%s: %s, ", k, v))
+		if token == "" {
+			return nil
 		}
-		hb.WriteString("]")
 
-		logger.LogAttrs(ctx, slog.LevelDebug, "request", slog.String("name", name),
-			slog.String("body", buf.String()), slog.String("headers", hb.String()))
+		query.Set(AppSecretProofParam, AppSecretProof(key, token))
+		request.URL.RawQuery = query.Encode()
 
 		return nil
 	}
 }
 
-func LogResponseHook(logger *slog.Logger) ResponseHook {
+// LogRequestHook returns a RequestHook that logs every request at the debug level. The
+// access token, app secret proof and other secrets are redacted from the headers, the URL
+// and the body, which is truncated. Phone numbers and message bodies are masked when the
+// options say so, see the redact package.
+func LogRequestHook(logger *slog.Logger, options ...redact.Option) RequestHook {
+	redactor := redact.New(options...)
+
+	return func(ctx context.Context, request *http.Request) error {
+		if !logger.Enabled(ctx, slog.LevelDebug) {
+			return nil
+		}
+
+		body, err := requestBody(request)
+		if err != nil {
+			return fmt.Errorf("log request hook: %w", err)
+		}
+
+		logger.LogAttrs(ctx, slog.LevelDebug, "request",
+			slog.String("name", RequestNameFromContext(ctx)),
+			slog.String("method", request.Method),
+			slog.String("url", redactor.URL(request.URL.String())),
+			slog.String("headers", formatHeaders(redactor.Headers(request.Header))),
+			slog.String("body", redactor.Body(request.Header.Get("Content-Type"), body)),
+		)
+
+		return nil
+	}
+}
+
+// LogResponseHook returns a ResponseHook that logs every response at the debug level, with
+// the same redaction as LogRequestHook.
+func LogResponseHook(logger *slog.Logger, options ...redact.Option) ResponseHook {
+	redactor := redact.New(options...)
+
 	return func(ctx context.Context, response *http.Response) error {
-		name := RequestNameFromContext(ctx)
-		reader := response.Body
-		buf := new(bytes.Buffer)
-		if _, err := buf.ReadFrom(reader); err != nil {
-			return fmt.Errorf("log response hook: %w", err)
+		if !logger.Enabled(ctx, slog.LevelDebug) {
+			return nil
 		}
 
-		hb := &strings.Builder{}
-		hb.WriteString("[")
-		for k, v := range response.Header {
-			hb.WriteString(fmt.Sprintf("%s: %s, ", k, v))
+		buf := new(bytes.Buffer)
+		if response.Body != nil {
+			if _, err := buf.ReadFrom(response.Body); err != nil {
+				return fmt.Errorf("log response hook: %w", err)
+			}
 		}
-		hb.WriteString("]")
 
-		logger.LogAttrs(ctx, slog.LevelDebug, "response", slog.String("name", name),
+		logger.LogAttrs(ctx, slog.LevelDebug, "response", slog.String("name", RequestNameFromContext(ctx)),
 			slog.Int("status", response.StatusCode),
 			slog.String("status_text", response.Status),
-			slog.String("headers", hb.String()),
-			slog.String("body", buf.String()),
+			slog.String("headers", formatHeaders(redactor.Headers(response.Header))),
+			slog.String("body", redactor.Body(response.Header.Get("Content-Type"), buf.Bytes())),
 		)
 
 		return nil
 	}
 }
+
+// requestBody returns a copy of the body of request without consuming it.
+func requestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody || request.GetBody == nil {
+		return nil, nil
+	}
+
+	reader, err := request.GetBody()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader) //nolint:wrapcheck
+}
+
+func formatHeaders(headers http.Header) string {
+	hb := &strings.Builder{}
+	hb.WriteString("[")
+	for k, v := range headers {
+		hb.WriteString(fmt.Sprintf("%s: %s, ", k, v))
+	}
+	hb.WriteString("]")
+
+	return hb.String()
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/piusalfred/whatsapp/pkg/redact"
+)
+
+func TestAppSecretProofHook(t *testing.T) {
+	t.Parallel()
+	// HMAC-SHA256 of "token" keyed by "secret"
+	const proof = "e941110e3d2bfe82621f0e3e1434730d7305d106c5f68c87165d0b27a4611a4a"
+
+	tests := []struct {
+		name   string
+		url    string
+		bearer string
+		want   string
+	}{
+		{name: "bearer header", url: "https://example.com/v16.0/1234/messages", bearer: "token", want: proof},
+		{name: "query parameter", url: "https://example.com/v16.0/1234/message_qrdls?access_token=token", want: proof},
+		{name: "no token", url: "https://example.com/v16.0/1234/messages", want: ""},
+	}
+
+	hook := AppSecretProofHook("secret")
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			request, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.bearer != "" {
+				request.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+
+			if err = hook(context.TODO(), request); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := request.URL.Query().Get(AppSecretProofParam); got != tt.want {
+				t.Errorf("appsecret_proof = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogRequestHook(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		body    string
+		options []redact.Option
+		want    []string
+	}{
+		{name: "no body", want: []string{"Bearer [REDACTED]", "access_token=[REDACTED]"}},
+		{
+			name: "masked body",
+			body: `{"to":"255712345678","text":{"body":"hello"}}`,
+			options: []redact.Option{
+				redact.MaskPhoneNumbers(), redact.MaskMessageBodies(),
+			},
+			want: []string{"********5678", `\"text\":\"[REDACTED]\"`},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var (
+				buf    bytes.Buffer
+				body   io.Reader
+				logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			)
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			request, err := http.NewRequestWithContext(context.TODO(), http.MethodPost,
+				"https://example.com/v16.0/1234/messages?access_token=EAAG", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Authorization", "Bearer EAAG")
+
+			if err = LogRequestHook(logger, tt.options...)(context.TODO(), request); err != nil {
+				t.Fatal(err)
+			}
+
+			logged := buf.String()
+			if strings.Contains(logged, "EAAG") || strings.Contains(logged, "255712345678") {
+				t.Errorf("secret logged: %s", logged)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(logged, want) {
+					t.Errorf("log %s does not contain %s", logged, want)
+				}
+			}
+		})
+	}
+}
@@ -22,7 +22,6 @@ package http
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -30,8 +29,12 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	werrors "github.com/piusalfred/whatsapp/pkg/errors"
+	"github.com/piusalfred/whatsapp/pkg/codec"
+	"github.com/piusalfred/whatsapp/pkg/redact"
 )
 
 const (
@@ -44,29 +47,52 @@ type (
 		http          *http.Client
 		requestHooks  []RequestHook
 		responseHooks []ResponseHook
-		errorChannel  chan error
+		headerHooks   []ResponseHook
+		codec         codec.Codec
+		logger        *slog.Logger
+		redactor      *redact.Redactor
+		breaker       *CircuitBreaker
+		events        []EventHandler
+
+		// errorChannel is read by ListenErrors. Errors are sent without blocking and
+		// dropped when it is full or closed.
+		errorChannel chan error
+		errorsMu     sync.RWMutex
+		closed       bool
+		dropped      atomic.Int64
 	}
 
 	ClientOption func(*Client)
 )
 
+// DefaultErrorBufferSize is the number of errors kept for ListenErrors.
+const DefaultErrorBufferSize = 64
+
 // ListenErrors takes a func(error) and returns nothing.
-// Every error sent to the client's errorChannel will be passed to the function.
+// Every error sent to the client's errorChannel will be passed to the function. It returns
+// when the client is closed. Errors that occur while the buffer is full are dropped and
+// counted by DroppedErrors, use WithEventHandler to receive every event instead.
 func (client *Client) ListenErrors(errorHandler func(error)) {
 	for err := range client.errorChannel {
 		errorHandler(err)
 	}
 }
 
-// Close closes the client.
+// Close closes the client. It is safe to call more than once.
 func (client *Client) Close() error {
-	close(client.errorChannel)
+	client.errorsMu.Lock()
+	defer client.errorsMu.Unlock()
+	if !client.closed {
+		client.closed = true
+		close(client.errorChannel)
+	}
 
 	return nil
 }
 
 // NewClient creates a new client with the given options, The client is used
-// to create a new http request and send it to the server.
+// to create a new http request and send it to the server. Without WithHTTPClient it
+// uses an http.Client, shared by all clients, with the transport of NewTransport.
 // Example:
 //
 //	client := NewClient(
@@ -80,8 +106,8 @@ func (client *Client) Close() error {
 //	)
 func NewClient(options ...ClientOption) *Client {
 	client := &Client{
-		http:         http.DefaultClient,
-		errorChannel: make(chan error),
+		http:         defaultHTTPClient,
+		errorChannel: make(chan error, DefaultErrorBufferSize),
 	}
 	for _, option := range options {
 		option(client)
@@ -108,6 +134,28 @@ func WithResponseHooks(hooks ...ResponseHook) ClientOption {
 	}
 }
 
+// WithResponseHeaderHooks adds hooks that only need the status and the headers of the
+// responses, such as UsageTracker.ResponseHook. They run before the body is read and do not
+// stop responses of a RawResponseDecoder from being streamed, which ResponseHooks do.
+func WithResponseHeaderHooks(hooks ...ResponseHook) ClientOption {
+	return func(client *Client) {
+		client.headerHooks = append(client.headerHooks, hooks...)
+	}
+}
+
+// WithCodec sets the codec that encodes the payloads and decodes the responses, the
+// default is codec.Default.
+func WithCodec(c codec.Codec) ClientOption {
+	return func(client *Client) {
+		client.codec = c
+	}
+}
+
+// SetCodec sets the codec of the client, see WithCodec.
+func (client *Client) SetCodec(c codec.Codec) {
+	client.codec = c
+}
+
 // SetRequestHooks sets the request hooks for the client, This removes any previously set request hooks.
 // and replaces it with the new ones.
 func (client *Client) SetRequestHooks(hooks ...RequestHook) {
@@ -153,114 +201,121 @@ func (client *Client) PrependResponseHooks(hooks ...ResponseHook) {
 // Do send a http request to the server and returns the response, It accepts a context,
 // a request and a pointer to a variable to decode the response into.
 func (client *Client) Do(ctx context.Context, r *Request, v any) error {
-	request, err := prepareRequest(ctx, r, client.requestHooks...)
-	if err != nil {
-		return fmt.Errorf("prepare request: %w", err)
-	}
+	return client.guard(r, func() error { return client.do(ctx, r, v) })
+}
 
-	response, err := client.http.Do(request)
-	if err != nil {
-		return fmt.Errorf("http send: %w", err)
-	}
+func (client *Client) do(ctx context.Context, r *Request, v any) error {
+	return client.roundTrip(ctx, r, func(response *http.Response, body []byte) error {
+		return decodeResponseBody(client.codec, response, body, v)
+	}, nil)
+}
 
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			// send error to error channel
-			client.errorChannel <- fmt.Errorf("closing response body: %w", err)
-		}
-	}(response.Body)
+// DoWithDecoder sends a http request to the server and returns the response, It accepts a context,
+// a request, a pointer to a variable to decode the response into and a response decoder.
+// A RawResponseDecoder reads the response as it arrives, without buffering it, unless the
+// client has response hooks that need the body.
+func (client *Client) DoWithDecoder(ctx context.Context, r *Request, decoder ResponseDecoder, v any) error {
+	return client.guard(r, func() error { return client.doWithDecoder(ctx, r, decoder, v) })
+}
 
-	bodyBytes, err := io.ReadAll(response.Body)
-	if err != nil && !errors.Is(err, io.EOF) {
-		return fmt.Errorf("reading response body: %w", err)
+func (client *Client) doWithDecoder(ctx context.Context, r *Request, decoder ResponseDecoder, v any) error {
+	raw, isRaw := decoder.(RawResponseDecoder)
+	if isRaw && len(client.responseHooks) == 0 {
+		return client.roundTrip(ctx, r, nil, raw)
 	}
 
-	response.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-
-	if err = runResponseHooks(ctx, response, client.responseHooks...); err != nil {
-		return fmt.Errorf("response hooks: %w", err)
-	}
-	response.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	return client.roundTrip(ctx, r, func(response *http.Response, body []byte) error {
+		// decoders may keep the body, they read a copy of the pooled buffer
+		response.Body = io.NopCloser(bytes.NewReader(bytes.Clone(body)))
+		if isRaw {
+			return raw(response)
+		}
 
-	return decodeResponseJSON(response, v)
+		return decoder.DecodeResponse(response, v)
+	}, nil)
 }
 
-// DoWithDecoder sends a http request to the server and returns the response, It accepts a context,
-// a request, a pointer to a variable to decode the response into and a response decoder.
-func (client *Client) DoWithDecoder(ctx context.Context, r *Request, decoder ResponseDecoder, v any) error {
-	request, err := prepareRequest(ctx, r, client.requestHooks...)
+// roundTrip sends the request. With stream set the response is passed to it as it arrives,
+// after the header hooks ran. Otherwise the body is read once into a pooled buffer that is
+// passed to decode, which may not keep it after returning. The response hooks read a copy
+// of it, so they may keep it.
+func (client *Client) roundTrip(ctx context.Context, r *Request, decode func(*http.Response, []byte) error,
+	stream RawResponseDecoder,
+) error {
+	request, err := client.prepareRequest(ctx, r)
 	if err != nil {
 		return fmt.Errorf("prepare request: %w", err)
 	}
 
+	start := time.Now()
+	client.logRequest(ctx, r, request)
 	response, err := client.http.Do(request)
 	if err != nil {
+		client.logFailure(ctx, r, request, start, err)
+
 		return fmt.Errorf("http send: %w", err)
 	}
 
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			// send error to error channel
-			client.errorChannel <- fmt.Errorf("closing response body: %w", err)
-		}
-	}(response.Body)
+	defer client.closeBody(ctx, r, response.Body)
 
-	bodyBytes, err := io.ReadAll(response.Body)
-	if err != nil && !errors.Is(err, io.EOF) {
-		return fmt.Errorf("reading response body: %w", err)
+	if err = client.runHeaderHooks(ctx, r, response); err != nil {
+		return fmt.Errorf("response hooks: %w", err)
 	}
 
-	response.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	if stream != nil {
+		client.logResponse(ctx, r, response, nil, start)
 
-	if err = runResponseHooks(ctx, response, client.responseHooks...); err != nil {
-		return fmt.Errorf("response hooks: %w", err)
+		return stream(response)
 	}
 
-	response.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	buf := getBuffer()
+	defer putBuffer(buf)
 
-	noctx, ok := decoder.(RawResponseDecoder)
-	if ok {
-		return noctx(response)
+	if _, err = buf.ReadFrom(response.Body); err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+	body := buf.Bytes()
+	client.logResponse(ctx, r, response, body, start)
+
+	if len(client.responseHooks) > 0 {
+		response.Body = io.NopCloser(bytes.NewReader(bytes.Clone(body)))
+		if err = client.runResponseHooks(ctx, r, response); err != nil {
+			return fmt.Errorf("response hooks: %w", err)
+		}
+	}
+
+	// the pooled buffer is only handed to decode, not left behind in the response
+	response.Body = http.NoBody
+
+	return decode(response, body)
+}
+
+// guard runs fn through the circuit breaker of the client, if any.
+func (client *Client) guard(r *Request, fn func() error) error {
+	if client.breaker == nil || r == nil {
+		return fn()
 	}
 
-	return decoder.DecodeResponse(response, v)
+	return client.breaker.Execute(CircuitKey(r.Context), fn)
 }
 
 var ErrRequestFailed = errors.New("request failed")
 
-func decodeResponseJSON(response *http.Response, v interface{}) error {
+// decodeResponseBody decodes body, the body of response, into v with c. The body is copied
+// into the ResponseError of a failed response, it can be a pooled buffer.
+func decodeResponseBody(c codec.Codec, response *http.Response, body []byte, v interface{}) error {
 	if v == nil || response == nil {
 		return nil
 	}
 
-	responseBody, err := io.ReadAll(response.Body)
-	if err != nil {
-		return fmt.Errorf("error reading response body: %w", err)
-	}
-
-	defer func() {
-		response.Body = io.NopCloser(bytes.NewBuffer(responseBody))
-	}()
-
 	isResponseOk := response.StatusCode >= http.StatusOK && response.StatusCode <= http.StatusIMUsed
 
 	if !isResponseOk {
-		if len(responseBody) == 0 {
-			return fmt.Errorf("%w: status code: %d", ErrRequestFailed, response.StatusCode)
-		}
-
-		var errorResponse ResponseError
-		if err := json.Unmarshal(responseBody, &errorResponse); err != nil {
-			return fmt.Errorf("error decoding response error body: %w", err)
-		}
-
-		return &errorResponse
+		return NewResponseErrorWithCodec(c, response, bytes.Clone(body))
 	}
 
-	if len(responseBody) != 0 {
-		if err := json.Unmarshal(responseBody, v); err != nil {
+	if len(body) != 0 {
+		if err := codec.OrDefault(c).Unmarshal(body, v); err != nil {
 			return fmt.Errorf("error decoding response body: %w", err)
 		}
 	}
@@ -268,10 +323,12 @@ func decodeResponseJSON(response *http.Response, v interface{}) error {
 	return nil
 }
 
-func runResponseHooks(ctx context.Context, response *http.Response, hooks ...ResponseHook) error {
-	for _, hook := range hooks {
+func (client *Client) runResponseHooks(ctx context.Context, r *Request, response *http.Response) error {
+	for _, hook := range client.responseHooks {
 		if hook != nil {
 			if err := hook(ctx, response); err != nil {
+				client.Report(ctx, &Event{Kind: EventResponseHookFailed, RequestName: requestName(r), Err: err})
+
 				return fmt.Errorf("response hooks: %w", err)
 			}
 		}
@@ -280,32 +337,71 @@ func runResponseHooks(ctx context.Context, response *http.Response, hooks ...Res
 	return nil
 }
 
-func prepareRequest(ctx context.Context, r *Request, hooks ...RequestHook) (*http.Request, error) {
+// runHeaderHooks runs the hooks that only look at the status and the headers, before the
+// body is read. The body is hidden from them.
+func (client *Client) runHeaderHooks(ctx context.Context, r *Request, response *http.Response) error {
+	if len(client.headerHooks) == 0 {
+		return nil
+	}
+
+	body := response.Body
+	response.Body = http.NoBody
+	defer func() { response.Body = body }()
+
+	for _, hook := range client.headerHooks {
+		if hook != nil {
+			if err := hook(ctx, response); err != nil {
+				client.Report(ctx, &Event{Kind: EventResponseHookFailed, RequestName: requestName(r), Err: err})
+
+				return fmt.Errorf("response header hooks: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// closeBody closes the response body and reports a failure to do so.
+func (client *Client) closeBody(ctx context.Context, r *Request, body io.ReadCloser) {
+	if err := body.Close(); err != nil {
+		client.Report(ctx, &Event{
+			Kind:        EventBodyCloseFailed,
+			RequestName: requestName(r),
+			Err:         fmt.Errorf("closing response body: %w", err),
+		})
+	}
+}
+
+func (client *Client) prepareRequest(ctx context.Context, r *Request) (*http.Request, error) {
 	// create a new request, run hooks and return the request after restoring the body
 	ctx = withRequestName(ctx, r.Context.Name)
 
-	request, err := NewRequestWithContext(ctx, r)
+	request, err := newRequest(ctx, r, client.codec)
 	if err != nil {
 		return nil, fmt.Errorf("prepare request: %w", err)
 	}
 
 	// run request hooks
-	for _, hook := range hooks {
+	for _, hook := range client.requestHooks {
 		if hook != nil {
 			if err = hook(ctx, request); err != nil {
+				client.Report(ctx, &Event{Kind: EventRequestHookFailed, RequestName: requestName(r), Err: err})
+
 				return nil, fmt.Errorf("prepare request: %w", err)
 			}
 		}
 	}
 
-	// restore the request body
-	body, err := r.BodyBytes()
-	if err != nil {
-		return nil, fmt.Errorf("prepare request: %w", err)
+	// hooks may have read the body, replay it. A payload that is a plain io.Reader
+	// cannot be replayed and is sent as the hooks left it.
+	if len(client.requestHooks) > 0 && request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("prepare request: %w", err)
+		}
+		request.Body = body
 	}
 
-	request.Body = io.NopCloser(bytes.NewBuffer(body))
-
 	return request, nil
 }
 
@@ -332,6 +428,9 @@ type (
 		Form      map[string]string
 		Payload   any
 		Metadata  map[string]string // This is used to pass metadata for other uses cases like logging, instrumentation etc.
+
+		// TokenSource, when set, supplies the bearer token instead of Bearer.
+		TokenSource TokenSource
 	}
 
 	RequestOption func(*Request)
@@ -344,16 +443,31 @@ type (
 		Bearer            string
 		BusinessAccountID string
 		Endpoints         []string
+
+		// TokenSource, when set, supplies the bearer token instead of Bearer. It is used by
+		// the requests that do not have a TokenSource of their own.
+		TokenSource TokenSource
+
+		// Route, when set, is the path of the request below the version, and PhoneNumberID
+		// and Endpoints are not used to build the URL.
+		Route *Route
 	}
 )
 
+// LogValue returns the request as a group of attributes. Secrets in the headers, the query
+// and the URL are redacted and the bearer token and the payload are never logged.
 func (request *Request) LogValue() slog.Value {
 	if request == nil {
 		return slog.StringValue("nil")
 	}
-	var reqURL string
+	var (
+		reqURL   string
+		name     string
+		redactor *redact.Redactor
+	)
 	if request.Context != nil {
-		reqURL, _ = url.JoinPath(request.Context.BaseURL, request.Context.Endpoints...)
+		reqURL, _ = RequestURLFromContext(request.Context)
+		name = request.Context.Name
 	}
 
 	var metadataAttr []any
@@ -365,19 +479,22 @@ func (request *Request) LogValue() slog.Value {
 	var headersAttr []any
 
 	for key, value := range request.Headers {
-		headersAttr = append(headersAttr, slog.String(key, value))
+		headersAttr = append(headersAttr, slog.String(key, redactor.Header(key, value)))
 	}
 
 	var queryAttr []any
 
 	for key, value := range request.Query {
+		if redact.IsSecret(key) {
+			value = redact.Redacted
+		}
 		queryAttr = append(queryAttr, slog.String(key, value))
 	}
 
 	value := slog.GroupValue(
-		slog.String("name", request.Context.Name),
+		slog.String("name", name),
 		slog.String("method", request.Method),
-		slog.String("url", reqURL),
+		slog.String("url", redactor.URL(reqURL)),
 		slog.Group("metadata", metadataAttr...),
 		slog.Group("headers", headersAttr...),
 		slog.Group("query", queryAttr...),
@@ -459,6 +576,12 @@ func WithEndpoints(endpoints ...string) RequestOption {
 	}
 }
 
+func WithTokenSource(source TokenSource) RequestOption {
+	return func(request *Request) {
+		request.TokenSource = source
+	}
+}
+
 func WithBearer(bearer string) RequestOption {
 	return func(request *Request) {
 		request.Bearer = bearer
@@ -498,6 +621,11 @@ var ErrInvalidRequestValue = errors.New("invalid request value")
 
 // NewRequestWithContext takes a context and a *Request and returns a new *http.Request.
 func NewRequestWithContext(ctx context.Context, request *Request) (*http.Request, error) {
+	return newRequest(ctx, request, codec.Default)
+}
+
+// newRequest is NewRequestWithContext with the codec that encodes the payload.
+func newRequest(ctx context.Context, request *Request, c codec.Codec) (*http.Request, error) {
 	if request == nil || request.Context == nil {
 		return nil, fmt.Errorf("%w: request or request context should not be nil", ErrInvalidRequestValue)
 	}
@@ -514,7 +642,7 @@ func NewRequestWithContext(ctx context.Context, request *Request) (*http.Request
 		body = strings.NewReader(form.Encode())
 		headers["Content-Type"] = "application/x-www-form-urlencoded"
 	} else if request.Payload != nil {
-		rdr, err := extractRequestBody(request.Payload)
+		rdr, err := encodeRequestBody(c, request.Payload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to extract payload from request: %w", err)
 		}
@@ -550,16 +678,29 @@ func NewRequestWithContext(ctx context.Context, request *Request) (*http.Request
 	}
 
 	// Set the bearer token header
-	if request.Bearer != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", request.Bearer))
+	bearer, err := request.bearerToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bearer token: %w", err)
+	}
+
+	auth := AuthBearer
+	if request.Context.Route != nil {
+		auth = request.Context.Route.Auth
+	}
+
+	if bearer != "" && auth == AuthBearer {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", bearer))
 	}
 
 	// Add the query parameters to the request URL
-	if request.Query != nil {
+	if request.Query != nil || (bearer != "" && auth == AuthQuery) {
 		query := req.URL.Query()
 		for key, value := range request.Query {
 			query.Add(key, value)
 		}
+		if bearer != "" && auth == AuthQuery && !query.Has("access_token") {
+			query.Set("access_token", bearer)
+		}
 		req.URL.RawQuery = query.Encode()
 	}
 
@@ -575,7 +716,16 @@ func NewRequestWithContext(ctx context.Context, request *Request) (*http.Request
 // 3. string
 // 4. any value that can be marshalled to json
 // 5. nil.
+//
+// Every type but io.Reader is returned as a *bytes.Reader or a *strings.Reader, which lets
+// http.NewRequestWithContext set GetBody so that the body can be replayed without being
+// encoded again.
 func extractRequestBody(payload interface{}) (io.Reader, error) {
+	return encodeRequestBody(codec.Default, payload)
+}
+
+// encodeRequestBody is extractRequestBody with the codec that encodes values.
+func encodeRequestBody(c codec.Codec, payload interface{}) (io.Reader, error) {
 	if payload == nil {
 		return nil, nil
 	}
@@ -587,13 +737,12 @@ func extractRequestBody(payload interface{}) (io.Reader, error) {
 	case string:
 		return strings.NewReader(p), nil
 	default:
-		buf := &bytes.Buffer{}
-		err := json.NewEncoder(buf).Encode(p)
+		data, err := codec.OrDefault(c).Marshal(p)
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode payload: %w", err)
 		}
 
-		return buf, nil
+		return bytes.NewReader(data), nil
 	}
 }
 
@@ -624,6 +773,10 @@ func RequestNameFromContext(ctx context.Context) string {
 
 // RequestURLFromContext returns the request url from the context.
 func RequestURLFromContext(ctx *RequestContext) (string, error) {
+	if ctx.Route != nil {
+		return ctx.Route.URL(ctx.BaseURL, ctx.ApiVersion)
+	}
+
 	elems := append([]string{ctx.ApiVersion, ctx.PhoneNumberID}, ctx.Endpoints...)
 	path, err := url.JoinPath(ctx.BaseURL, elems...)
 	if err != nil {
@@ -633,21 +786,6 @@ func RequestURLFromContext(ctx *RequestContext) (string, error) {
 	return path, nil
 }
 
-type ResponseError struct {
-	Code int            `json:"code,omitempty"`
-	Err  *werrors.Error `json:"error,omitempty"`
-}
-
-// Error returns the error message for ResponseError.
-func (e *ResponseError) Error() string {
-	return fmt.Sprintf("whatsapp error: http code: %d, %s", e.Code, strings.ToLower(e.Err.Error()))
-}
-
-// Unwrap returns the underlying error for ResponseError.
-func (e *ResponseError) Unwrap() error {
-	return e.Err
-}
-
 type (
 
 	// ResponseDecoder decodes the response body into the given interface.
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+type benchmarkMessage struct {
+	Product       string            `json:"messaging_product"`
+	To            string            `json:"to"`
+	RecipientType string            `json:"recipient_type"`
+	Type          string            `json:"type"`
+	Text          map[string]string `json:"text"`
+}
+
+func benchmarkClient(body []byte) *Client {
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if request.Body != nil {
+			_, _ = io.Copy(io.Discard, request.Body)
+			_ = request.Body.Close()
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    request,
+		}, nil
+	})
+
+	return NewClient(WithHTTPClient(&http.Client{Transport: transport}))
+}
+
+func benchmarkRequest() *Request {
+	return &Request{
+		Context: &RequestContext{
+			Name:          "send message",
+			BaseURL:       BaseURL,
+			ApiVersion:    DefaultAPIVersion,
+			PhoneNumberID: "1234567890",
+			Route:         PhoneNumberRoute("1234567890", "messages"),
+		},
+		Method:  http.MethodPost,
+		Bearer:  "token",
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Payload: &benchmarkMessage{
+			Product:       "whatsapp",
+			To:            "255700000000",
+			RecipientType: "individual",
+			Type:          "text",
+			Text:          map[string]string{"body": "Habari yako? This is a benchmark message."},
+		},
+	}
+}
+
+func BenchmarkClientDo(b *testing.B) {
+	client := benchmarkClient([]byte(`{"messaging_product":"whatsapp","contacts":[{"input":"255700000000",` +
+		`"wa_id":"255700000000"}],"messages":[{"id":"wamid.HBgLMjU1NzAwMDAwMDAwFQIAERgSQkE2"}]}`))
+	request := benchmarkRequest()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var response map[string]any
+		if err := client.Do(context.Background(), request, &response); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkClientDownload(b *testing.B) {
+	client := benchmarkClient(bytes.Repeat([]byte("0123456789abcdef"), 64*1024))
+	request := &Request{
+		Context: &RequestContext{Name: "download media", Route: URLRoute("https://lookaside.fbsbx.com/media")},
+		Method:  http.MethodGet,
+		Bearer:  "token",
+	}
+	decoder := RawResponseDecoder(func(response *http.Response) error {
+		_, err := io.Copy(io.Discard, response.Body)
+
+		return err //nolint:wrapcheck
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := client.DoWithDecoder(context.Background(), request, decoder, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
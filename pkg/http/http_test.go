@@ -23,9 +23,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
 )
 
 type Context struct {
@@ -310,3 +315,100 @@ func Test_extractRequestBody(t *testing.T) {
 		})
 	}
 }
+
+type countingCodec struct {
+	codec.JSON
+	marshal, unmarshal int
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshal++
+
+	return c.JSON.Marshal(v) //nolint:wrapcheck
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshal++
+
+	return c.JSON.Unmarshal(data, v) //nolint:wrapcheck
+}
+
+func TestClientCodec(t *testing.T) { //nolint:paralleltest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user User
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil || user.Name != "Pius" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"Pius","age":77,"male":true,"height":180}`))
+	}))
+	defer server.Close()
+
+	request := &Request{
+		Context: &RequestContext{Name: "codec", BaseURL: server.URL},
+		Method:  http.MethodPost,
+		Payload: &User{Name: "Pius"},
+	}
+
+	counter := &countingCodec{}
+	client := NewClient(WithCodec(counter))
+	var user User
+	if err := client.Do(context.TODO(), request, &user); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if counter.marshal != 1 || counter.unmarshal != 1 || user.Age != 77 {
+		t.Errorf("marshal = %d, unmarshal = %d, user = %+v", counter.marshal, counter.unmarshal, user)
+	}
+
+	client.SetCodec(codec.Strict())
+	if err := client.Do(context.TODO(), request, &user); !errors.Is(err, codec.ErrUnknownField) {
+		t.Errorf("Do() error = %v, want codec.ErrUnknownField", err)
+	}
+}
+
+func TestClientKeepsResponseBody(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"name":%q}`, r.URL.Query().Get("name"))
+	}))
+	defer server.Close()
+
+	var hooked []io.Reader
+	client := NewClient(WithResponseHooks(func(_ context.Context, response *http.Response) error {
+		hooked = append(hooked, response.Body)
+
+		return nil
+	}))
+
+	var decoded []io.Reader
+	decoder := ResponseDecoderFunc(func(response *http.Response, _ interface{}) error {
+		decoded = append(decoded, response.Body)
+
+		return nil
+	})
+
+	// the bodies are read after all the calls, when a pooled buffer would have been reused
+	const calls = 10
+	for i := 0; i < calls; i++ {
+		request := &Request{
+			Context: &RequestContext{Name: "keep body", BaseURL: server.URL},
+			Method:  http.MethodGet,
+			Query:   map[string]string{"name": fmt.Sprintf("user-%d", i)},
+		}
+		if err := client.DoWithDecoder(context.TODO(), request, decoder, nil); err != nil {
+			t.Fatalf("DoWithDecoder() error = %v", err)
+		}
+	}
+
+	for i := 0; i < calls; i++ {
+		want := fmt.Sprintf(`{"name":"user-%d"}`, i)
+		for _, body := range []io.Reader{hooked[i], decoded[i]} {
+			got, err := io.ReadAll(body)
+			if err != nil || string(got) != want {
+				t.Errorf("body of call %d = %q, %v, want %q", i, got, err, want)
+			}
+		}
+	}
+}
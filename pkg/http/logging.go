@@ -0,0 +1,118 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/piusalfred/whatsapp/pkg/redact"
+)
+
+// WithLogger makes the client log its calls to logger at the debug level: requests,
+// responses, including error responses, and failed calls. A failed call is returned to the
+// caller, which decides how to log it, whatsapp.LogOperationMiddleware logs it at the
+// warning level. The events of the client are logged with logEvent. Secrets are always
+// redacted, the options can mask phone numbers and message bodies and set how much of a
+// body is logged.
+func WithLogger(logger *slog.Logger, options ...redact.Option) ClientOption {
+	return func(client *Client) {
+		client.SetLogger(logger, options...)
+	}
+}
+
+// SetLogger sets the logger of the client, see WithLogger. A nil logger turns logging off.
+func (client *Client) SetLogger(logger *slog.Logger, options ...redact.Option) {
+	client.logger = logger
+	client.redactor = redact.New(options...)
+}
+
+func (client *Client) logRequest(ctx context.Context, r *Request, request *http.Request) {
+	if client.logger == nil || !client.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("name", requestName(r)),
+		slog.String("method", request.Method),
+		slog.String("url", client.redactor.URL(request.URL.String())),
+	}
+	if body, err := requestBody(request); err == nil && len(body) > 0 {
+		attrs = append(attrs, slog.String("body", client.redactor.Body(request.Header.Get("Content-Type"), body)))
+	}
+
+	client.logger.LogAttrs(ctx, slog.LevelDebug, "whatsapp request", attrs...)
+}
+
+func (client *Client) logFailure(ctx context.Context, r *Request, request *http.Request, start time.Time,
+	err error,
+) {
+	if client.logger == nil || !client.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	client.logger.LogAttrs(ctx, slog.LevelDebug, "whatsapp request failed",
+		slog.String("name", requestName(r)),
+		slog.String("method", request.Method),
+		slog.String("url", client.redactor.URL(request.URL.String())),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("error", err.Error()),
+	)
+}
+
+// logResponse logs response, body is nil when the response is streamed to the caller.
+func (client *Client) logResponse(ctx context.Context, r *Request, response *http.Response, body []byte,
+	start time.Time,
+) {
+	if client.logger == nil || !client.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("name", requestName(r)),
+		slog.Int("status", response.StatusCode),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("request_id", response.Header.Get("X-Fb-Request-Id")),
+	}
+	if len(body) > 0 {
+		attrs = append(attrs, slog.String("body", client.redactor.Body(response.Header.Get("Content-Type"), body)))
+	}
+
+	client.logger.LogAttrs(ctx, slog.LevelDebug, "whatsapp response", attrs...)
+}
+
+// logEvent logs event like SlogEventHandler, except for the hook failures that are also
+// returned to the caller, which are logged at the debug level so that a failed call is
+// not logged at the warning level twice.
+func (client *Client) logEvent(ctx context.Context, event *Event) {
+	if client.logger == nil {
+		return
+	}
+
+	if event.Kind == EventRequestHookFailed || event.Kind == EventResponseHookFailed {
+		client.logger.LogAttrs(ctx, slog.LevelDebug, "whatsapp http event", slog.Any("event", event))
+
+		return
+	}
+
+	SlogEventHandler(client.logger)(ctx, event)
+}
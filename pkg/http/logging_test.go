@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piusalfred/whatsapp/pkg/redact"
+)
+
+func TestClientLogger(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"invalid parameter","code":100}}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient(WithLogger(logger, redact.MaskPhoneNumbers()))
+
+	request := &Request{
+		Context: &RequestContext{
+			Name:    "send message",
+			BaseURL: server.URL,
+			Route:   PhoneNumberRoute("1234", "messages").WithAuth(AuthQuery),
+		},
+		Method:  http.MethodPost,
+		Bearer:  "EAAG",
+		Payload: map[string]string{"to": "255712345678"},
+	}
+	if err := client.Do(context.TODO(), request, &struct{}{}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	logged := buf.String()
+	if strings.Contains(logged, "EAAG") || strings.Contains(logged, "255712345678") {
+		t.Errorf("secret logged: %s", logged)
+	}
+
+	for _, want := range []string{`msg="whatsapp request"`, "status=400", "********5678"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log %s does not contain %s", logged, want)
+		}
+	}
+
+	// the failed call is returned to the caller, which logs it at the warning level
+	if strings.Contains(logged, "level=WARN") {
+		t.Errorf("failed call logged at the warning level by the http client: %s", logged)
+	}
+}
+
+func TestRequestLogValue(t *testing.T) {
+	t.Parallel()
+	request := &Request{
+		Context: &RequestContext{Name: "list phone numbers", BaseURL: "https://example.com", ApiVersion: "v16.0"},
+		Method:  http.MethodGet,
+		Headers: map[string]string{"Authorization": "Bearer EAAG"},
+		Query:   map[string]string{"access_token": "EAAG", "fields": "id"},
+	}
+
+	value := request.LogValue().String()
+	if strings.Contains(value, "EAAG") || !strings.Contains(value, "fields") {
+		t.Errorf("LogValue() = %s", value)
+	}
+
+	if got := (&Request{}).LogValue().String(); got == "" {
+		t.Errorf("LogValue() of a request without context is empty")
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/piusalfred/whatsapp/pkg/metrics"
+)
+
+const (
+	// MetricRequests counts the requests sent, by operation.
+	MetricRequests = "whatsapp_http_requests_total"
+
+	// MetricResponses counts the responses received, by operation and status code.
+	MetricResponses = "whatsapp_http_responses_total"
+
+	// MetricEvents counts the events of a Client, like hook failures and retries, by kind.
+	MetricEvents = "whatsapp_http_events_total"
+)
+
+// WithMetrics records the requests, the responses and the events of the client in registry,
+// see SetMetrics.
+func WithMetrics(registry *metrics.Registry) ClientOption {
+	return func(client *Client) {
+		client.SetMetrics(registry)
+	}
+}
+
+// SetMetrics adds MetricsRequestHook to the request hooks of the client, MetricsResponseHook
+// to its response header hooks, so that streamed responses are counted too, and
+// MetricsEventHandler to its event handlers.
+func (client *Client) SetMetrics(registry *metrics.Registry) {
+	client.requestHooks = append(client.requestHooks, MetricsRequestHook(registry))
+	client.headerHooks = append(client.headerHooks, MetricsResponseHook(registry))
+	client.events = append(client.events, MetricsEventHandler(registry))
+}
+
+// MetricsRequestHook returns a RequestHook that counts the requests by operation in
+// registry, the operation being the name of the request.
+func MetricsRequestHook(registry *metrics.Registry) RequestHook {
+	requests := registry.Counter(MetricRequests, "Requests sent to the WhatsApp API.", "operation")
+
+	return func(ctx context.Context, _ *http.Request) error {
+		requests.Inc(RequestNameFromContext(ctx))
+
+		return nil
+	}
+}
+
+// MetricsResponseHook returns a ResponseHook that counts the responses by operation and
+// status code in registry. It only looks at the status, so it can be a header hook.
+func MetricsResponseHook(registry *metrics.Registry) ResponseHook {
+	responses := registry.Counter(MetricResponses, "Responses received from the WhatsApp API.",
+		"operation", "status")
+
+	return func(ctx context.Context, response *http.Response) error {
+		// the name is in the context of the request, header hooks get the one of the call
+		if response.Request != nil {
+			ctx = response.Request.Context()
+		}
+		responses.Inc(RequestNameFromContext(ctx), strconv.Itoa(response.StatusCode))
+
+		return nil
+	}
+}
+
+// MetricsEventHandler returns an EventHandler that counts the events by kind in registry.
+func MetricsEventHandler(registry *metrics.Registry) EventHandler {
+	events := registry.Counter(MetricEvents, "Events of the WhatsApp http client.", "operation", "kind")
+
+	return func(_ context.Context, event *Event) {
+		events.Inc(event.RequestName, string(event.Kind))
+	}
+}
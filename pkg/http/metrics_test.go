@@ -0,0 +1,79 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piusalfred/whatsapp/pkg/metrics"
+)
+
+func TestClientMetrics(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	registry := metrics.NewRegistry()
+	client := NewClient(WithMetrics(registry))
+
+	for _, endpoint := range []string{"found", "missing"} {
+		request := &Request{
+			Context: &RequestContext{Name: "get " + endpoint, BaseURL: server.URL, Endpoints: []string{endpoint}},
+			Method:  http.MethodGet,
+		}
+		_ = client.Do(context.TODO(), request, &struct{}{})
+	}
+
+	// a streamed response is counted by the header hook
+	request := &Request{Context: &RequestContext{Name: "stream", BaseURL: server.URL}, Method: http.MethodGet}
+	if err := client.DoWithDecoder(context.TODO(), request, RawResponseDecoder(func(*http.Response) error {
+		return nil
+	}), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	requests, responses := registry.Counter(MetricRequests, "", "operation"),
+		registry.Counter(MetricResponses, "", "operation", "status")
+	for _, tt := range []struct {
+		counter *metrics.CounterVec
+		labels  []string
+		want    float64
+	}{
+		{counter: requests, labels: []string{"get found"}, want: 1},
+		{counter: requests, labels: []string{"stream"}, want: 1},
+		{counter: responses, labels: []string{"get found", "200"}, want: 1},
+		{counter: responses, labels: []string{"get missing", "404"}, want: 1},
+		{counter: responses, labels: []string{"stream", "200"}, want: 1},
+	} {
+		if got := tt.counter.Value(tt.labels...); got != tt.want {
+			t.Errorf("%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
+	werrors "github.com/piusalfred/whatsapp/pkg/errors"
+	"github.com/piusalfred/whatsapp/pkg/redact"
+)
+
+// ResponseErrorHeaders are the response headers kept in a ResponseError. They identify the
+// request for Meta support and tell how close the app is to its rate limits.
+var ResponseErrorHeaders = []string{ //nolint:gochecknoglobals
+	"X-Fb-Trace-Id",
+	"X-Fb-Request-Id",
+	"X-Fb-Debug",
+	"Www-Authenticate",
+	"X-Business-Use-Case-Usage",
+	"X-App-Usage",
+	"X-Ad-Account-Usage",
+	"Retry-After",
+	"Content-Type",
+}
+
+// maxLoggedBodySize is the size of the body kept when a ResponseError is logged.
+const maxLoggedBodySize = 512
+
+var _ slog.LogValuer = (*ResponseError)(nil)
+
+// ResponseError is returned when the API responds with a non 2xx status code. Err is the
+// error in the response body, it is nil when the body is empty or is not a JSON error,
+// for example an HTML page from a proxy, in which case Body holds what was returned.
+type ResponseError struct {
+	// Code is the HTTP status code of the response.
+	Code int            `json:"code,omitempty"`
+	Err  *werrors.Error `json:"error,omitempty"`
+
+	// Headers holds the ResponseErrorHeaders found in the response.
+	Headers http.Header `json:"-"`
+
+	// RequestName is the name of the request, for example "send message", and Method and
+	// URL identify the request. The access token and the other secrets are removed from the URL.
+	RequestName string `json:"-"`
+	Method      string `json:"-"`
+	URL         string `json:"-"`
+
+	// Body is the raw body of the response. Error and LogValue show it redacted and truncated.
+	Body []byte `json:"-"`
+}
+
+// NewResponseError returns the ResponseError of a response with a non 2xx status code
+// and the given body, decoded with codec.Default.
+func NewResponseError(response *http.Response, body []byte) *ResponseError {
+	return NewResponseErrorWithCodec(codec.Default, response, body)
+}
+
+// NewResponseErrorWithCodec is NewResponseError with the codec that decodes the body. With a
+// strict codec, an error that has fields the library does not know is left in Body and Err is nil.
+func NewResponseErrorWithCodec(c codec.Codec, response *http.Response, body []byte) *ResponseError {
+	responseErr := &ResponseError{
+		Code:    response.StatusCode,
+		Headers: http.Header{},
+		Body:    body,
+	}
+
+	for _, key := range ResponseErrorHeaders {
+		if values := response.Header.Values(key); len(values) > 0 {
+			responseErr.Headers[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+
+	if request := response.Request; request != nil {
+		responseErr.RequestName = RequestNameFromContext(request.Context())
+		responseErr.Method = request.Method
+		if request.URL != nil {
+			responseErr.URL = redact.New().URL(request.URL.String())
+		}
+	}
+
+	var decoded struct {
+		Err *werrors.Error `json:"error"`
+	}
+	if len(body) > 0 && codec.OrDefault(c).Unmarshal(body, &decoded) == nil {
+		responseErr.Err = decoded.Err
+	}
+
+	return responseErr
+}
+
+// Error returns the error message for ResponseError.
+func (e *ResponseError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "whatsapp error: http code: %d", e.Code)
+	if e.RequestName != "" {
+		fmt.Fprintf(&b, ", request: %s", e.RequestName)
+	}
+
+	switch {
+	case e.Err != nil:
+		b.WriteString(", " + strings.ToLower(e.Err.Error()))
+	case len(e.Body) > 0:
+		fmt.Fprintf(&b, ", body: %s", e.redactedBody())
+	default:
+		b.WriteString(", empty body")
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the underlying error for ResponseError.
+func (e *ResponseError) Unwrap() error {
+	if e.Err == nil {
+		return ErrRequestFailed
+	}
+
+	return e.Err
+}
+
+// TraceID returns the fbtrace_id of the failed request, from the body or the headers.
+func (e *ResponseError) TraceID() string {
+	if e.Err != nil && e.Err.FBTraceID != "" {
+		return e.Err.FBTraceID
+	}
+
+	return e.Headers.Get("X-Fb-Trace-Id")
+}
+
+// LogValue returns the details of the error that Meta support asks for.
+func (e *ResponseError) LogValue() slog.Value {
+	if e == nil {
+		return slog.StringValue("nil")
+	}
+
+	attrs := []slog.Attr{
+		slog.Int("status", e.Code),
+		slog.String("request", e.RequestName),
+		slog.String("method", e.Method),
+		slog.String("url", e.URL),
+		slog.String("trace_id", e.TraceID()),
+		slog.String("request_id", e.Headers.Get("X-Fb-Request-Id")),
+	}
+
+	if e.Err != nil {
+		attrs = append(attrs,
+			slog.Int("code", e.Err.Code),
+			slog.Int("subcode", e.Err.Subcode),
+			slog.String("type", e.Err.Type),
+			slog.String("message", e.Err.Message),
+		)
+		if e.Err.Data != nil && e.Err.Data.Details != "" {
+			attrs = append(attrs, slog.String("details", e.Err.Data.Details))
+		}
+	} else {
+		attrs = append(attrs, slog.String("body", e.redactedBody()))
+	}
+
+	if auth := e.Headers.Get("Www-Authenticate"); auth != "" {
+		attrs = append(attrs, slog.String("www_authenticate", auth))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// redactedBody returns Body with the secrets removed, cut to maxLoggedBodySize.
+func (e *ResponseError) redactedBody() string {
+	return redact.New(redact.MaxBodySize(maxLoggedBodySize)).Body(e.Headers.Get("Content-Type"), e.Body)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
+)
+
+func TestResponseError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Fb-Trace-Id", "header-trace")
+		w.Header().Set("X-Fb-Request-Id", "request-1")
+		w.Header().Set("X-Unrelated", "value")
+		w.WriteHeader(http.StatusBadRequest)
+		switch r.URL.Path {
+		case "/v16.0/html":
+			_, _ = fmt.Fprint(w, "<html>bad gateway</html>")
+
+			return
+		case "/v16.0/secret":
+			_, _ = fmt.Fprint(w, `{"access_token":"EAAG-leaked","message":"`+strings.Repeat("Ü", 300)+`"}`)
+
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"error":{"message":"invalid parameter","code":100,"fbtrace_id":"body-trace"}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	do := func(route *Route) *ResponseError {
+		t.Helper()
+		request := &Request{
+			Context: &RequestContext{Name: "test request", BaseURL: server.URL, ApiVersion: "v16.0", Route: route},
+			Method:  http.MethodGet,
+			Bearer:  "secret-token",
+		}
+
+		var out map[string]any
+		var responseErr *ResponseError
+		if err := client.Do(context.TODO(), request, &out); !errors.As(err, &responseErr) {
+			t.Fatalf("expected a ResponseError, got %v", err)
+		}
+
+		return responseErr
+	}
+
+	responseErr := do(RootRoute("json").WithAuth(AuthQuery))
+	if responseErr.Code != http.StatusBadRequest || responseErr.Err == nil || responseErr.Err.Code != 100 {
+		t.Errorf("unexpected error %+v", responseErr)
+	}
+
+	if responseErr.RequestName != "test request" || responseErr.TraceID() != "body-trace" ||
+		responseErr.Headers.Get("X-Fb-Request-Id") != "request-1" || responseErr.Headers.Get("X-Unrelated") != "" {
+		t.Errorf("unexpected request identity %+v", responseErr)
+	}
+
+	if strings.Contains(responseErr.URL, "secret-token") || !strings.Contains(responseErr.URL, "/v16.0/json") {
+		t.Errorf("unexpected url %q", responseErr.URL)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("failed", "error", responseErr)
+	for _, want := range []string{"error.status=400", "error.trace_id=body-trace", "error.request_id=request-1"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log %q does not contain %q", buf.String(), want)
+		}
+	}
+
+	responseErr = do(RootRoute("html"))
+	if responseErr.Err != nil || string(responseErr.Body) != "<html>bad gateway</html>" ||
+		!errors.Is(responseErr, ErrRequestFailed) || responseErr.TraceID() != "header-trace" {
+		t.Errorf("unexpected error for a non json body %+v", responseErr)
+	}
+
+	if !strings.Contains(responseErr.Error(), "bad gateway") {
+		t.Errorf("Error() = %q, want the body", responseErr.Error())
+	}
+
+	responseErr = do(RootRoute("secret"))
+	message, logged := responseErr.Error(), responseErr.LogValue().String()
+	for _, text := range []string{message, logged} {
+		if strings.Contains(text, "EAAG-leaked") || !strings.Contains(text, "bytes truncated") {
+			t.Errorf("body %q is not redacted and truncated", text)
+		}
+		if !utf8.ValidString(text) {
+			t.Errorf("body %q splits a multi-byte character", text)
+		}
+	}
+}
+
+func TestResponseErrorCodec(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"error":{"message":"invalid parameter","code":100,"is_transient":false}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name    string
+		codec   codec.Codec
+		decoded bool
+	}{
+		{name: "default codec", codec: codec.Default, decoded: true},
+		{name: "strict codec", codec: codec.Strict(), decoded: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			request := &Request{
+				Context: &RequestContext{Name: "test request", BaseURL: server.URL, Route: RootRoute("json")},
+				Method:  http.MethodGet,
+			}
+
+			var out map[string]any
+			var responseErr *ResponseError
+			err := NewClient(WithCodec(tt.codec)).Do(context.TODO(), request, &out)
+			if !errors.As(err, &responseErr) {
+				t.Fatalf("expected a ResponseError, got %v", err)
+			}
+
+			if (responseErr.Err != nil) != tt.decoded || len(responseErr.Body) == 0 {
+				t.Errorf("Err = %v, want decoded: %v", responseErr.Err, tt.decoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AuthMode is how the access token of a request is sent.
+type AuthMode int
+
+const (
+	// AuthBearer sends the token in the Authorization header.
+	AuthBearer AuthMode = iota
+
+	// AuthQuery sends the token as the access_token query parameter.
+	AuthQuery
+
+	// AuthNone sends no token, for nodes authenticated otherwise such as oauth/access_token.
+	AuthNone
+)
+
+// RouteNode is the kind of Graph API node a Route targets.
+type RouteNode string
+
+const (
+	NodeRoot            RouteNode = "root"
+	NodePhoneNumber     RouteNode = "phone_number"
+	NodeBusinessAccount RouteNode = "whatsapp_business_account"
+	NodeMedia           RouteNode = "media"
+	NodeApp             RouteNode = "application"
+	NodeBusiness        RouteNode = "business"
+	NodeQRCode          RouteNode = "qr_code"
+	NodeTemplate        RouteNode = "message_template"
+	NodeFlow            RouteNode = "flow"
+	NodeURL             RouteNode = "url"
+)
+
+// QRCodeEdge is the edge of a phone number that manages its QR codes.
+const QRCodeEdge = "message_qrdls"
+
+var ErrInvalidRoute = errors.New("invalid route")
+
+// Route is the path of a request below the API version: a node, such as a phone number or
+// a WhatsApp Business Account, followed by edges, such as messages or phone_numbers. Every
+// segment is escaped, so IDs and edges can hold any character. Set it as the Route of a
+// RequestContext instead of PhoneNumberID and Endpoints.
+//
+//	ctx := &RequestContext{
+//		BaseURL:    BaseURL,
+//		ApiVersion: "v16.0",
+//		Route:      BusinessAccountRoute(wabaID, "phone_numbers"),
+//	}
+type Route struct {
+	Node  RouteNode
+	ID    string
+	Edges []string
+	Auth  AuthMode
+	url   string
+}
+
+func newRoute(node RouteNode, id string, edges ...string) *Route {
+	return &Route{Node: node, ID: id, Edges: edges}
+}
+
+// RootRoute targets edges of the API root, for example debug_token or oauth/access_token.
+func RootRoute(edges ...string) *Route {
+	return newRoute(NodeRoot, "", edges...)
+}
+
+// PhoneNumberRoute targets a business phone number, for example its messages or media edge.
+func PhoneNumberRoute(phoneNumberID string, edges ...string) *Route {
+	return newRoute(NodePhoneNumber, phoneNumberID, edges...)
+}
+
+// BusinessAccountRoute targets a WhatsApp Business Account (WABA), for example its
+// phone_numbers or message_templates edge.
+func BusinessAccountRoute(businessAccountID string, edges ...string) *Route {
+	return newRoute(NodeBusinessAccount, businessAccountID, edges...)
+}
+
+// MediaRoute targets an uploaded media object.
+func MediaRoute(mediaID string, edges ...string) *Route {
+	return newRoute(NodeMedia, mediaID, edges...)
+}
+
+// AppRoute targets an app, for example its subscriptions edge.
+func AppRoute(appID string, edges ...string) *Route {
+	return newRoute(NodeApp, appID, edges...)
+}
+
+// BusinessRoute targets a business portfolio.
+func BusinessRoute(businessID string, edges ...string) *Route {
+	return newRoute(NodeBusiness, businessID, edges...)
+}
+
+// QRCodeRoute targets the QR codes of a phone number, or a single one when code is set.
+func QRCodeRoute(phoneNumberID, code string) *Route {
+	route := newRoute(NodeQRCode, phoneNumberID, QRCodeEdge)
+	if code != "" {
+		route.Edges = append(route.Edges, code)
+	}
+
+	return route
+}
+
+// TemplateRoute targets a message template.
+func TemplateRoute(templateID string, edges ...string) *Route {
+	return newRoute(NodeTemplate, templateID, edges...)
+}
+
+// FlowRoute targets a WhatsApp Flow, for example its assets edge.
+func FlowRoute(flowID string, edges ...string) *Route {
+	return newRoute(NodeFlow, flowID, edges...)
+}
+
+// URLRoute targets an absolute URL returned by the API, such as a media download URL. The
+// base URL and the version of the request are not used.
+func URLRoute(rawURL string) *Route {
+	return &Route{Node: NodeURL, url: rawURL}
+}
+
+// WithAuth returns a copy of the route sent with the given auth mode.
+func (route *Route) WithAuth(mode AuthMode) *Route {
+	clone := *route
+	clone.Edges = append([]string(nil), route.Edges...)
+	clone.Auth = mode
+
+	return &clone
+}
+
+// Path returns the escaped path of the route below the version, without a leading slash.
+func (route *Route) Path() (string, error) {
+	if route.Node != NodeRoot && route.ID == "" {
+		return "", fmt.Errorf("%w: %s route without an id", ErrInvalidRoute, route.Node)
+	}
+
+	segments := make([]string, 0, len(route.Edges)+1)
+	if route.ID != "" {
+		segments = append(segments, url.PathEscape(route.ID))
+	}
+
+	for _, edge := range route.Edges {
+		if edge == "" {
+			return "", fmt.Errorf("%w: empty edge in %s route", ErrInvalidRoute, route.Node)
+		}
+
+		// edges like oauth/access_token are made of several segments
+		for _, segment := range strings.Split(edge, "/") {
+			segments = append(segments, url.PathEscape(segment))
+		}
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+// URL returns the URL of the route for the base URL and the API version.
+func (route *Route) URL(baseURL, version string) (string, error) {
+	if route.Node == NodeURL {
+		if _, err := url.Parse(route.url); err != nil || route.url == "" {
+			return "", fmt.Errorf("%w: bad url %q", ErrInvalidRoute, route.url)
+		}
+
+		return route.url, nil
+	}
+
+	path, err := route.Path()
+	if err != nil {
+		return "", err
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return "", fmt.Errorf("%w: bad base url: %w", ErrInvalidRoute, err)
+	}
+
+	prefix := base.EscapedPath()
+	if version != "" {
+		prefix += "/" + url.PathEscape(version)
+	}
+
+	raw := base.Scheme + "://" + base.Host + prefix + "/" + path
+	if base.Scheme == "" {
+		raw = prefix + "/" + path
+	}
+
+	return raw, nil
+}
+
+// String returns the path of the route, or a description of why it is invalid.
+func (route *Route) String() string {
+	if route.Node == NodeURL {
+		return route.url
+	}
+
+	path, err := route.Path()
+	if err != nil {
+		return err.Error()
+	}
+
+	return path
+}
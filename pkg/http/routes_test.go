@@ -0,0 +1,145 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRouteURL(t *testing.T) {
+	t.Parallel()
+	const base = "https://graph.facebook.com/"
+
+	tests := []struct {
+		name    string
+		route   *Route
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "phone number messages",
+			route: PhoneNumberRoute("1234", "messages"),
+			want:  "https://graph.facebook.com/v16.0/1234/messages",
+		},
+		{
+			name:  "business account phone numbers",
+			route: BusinessAccountRoute("5678", "phone_numbers"),
+			want:  "https://graph.facebook.com/v16.0/5678/phone_numbers",
+		},
+		{
+			name:  "media",
+			route: MediaRoute("media-id"),
+			want:  "https://graph.facebook.com/v16.0/media-id",
+		},
+		{
+			name:  "qr code",
+			route: QRCodeRoute("1234", "ABC"),
+			want:  "https://graph.facebook.com/v16.0/1234/message_qrdls/ABC",
+		},
+		{
+			name:  "root with nested edge",
+			route: RootRoute("oauth/access_token"),
+			want:  "https://graph.facebook.com/v16.0/oauth/access_token",
+		},
+		{
+			name:  "escaped id",
+			route: TemplateRoute("a/b c?"),
+			want:  "https://graph.facebook.com/v16.0/a%2Fb%20c%3F",
+		},
+		{
+			name:  "absolute url",
+			route: URLRoute("https://lookaside.fbsbx.com/whatsapp_business/attachments/?mid=1"),
+			want:  "https://lookaside.fbsbx.com/whatsapp_business/attachments/?mid=1",
+		},
+		{
+			name:    "missing id",
+			route:   PhoneNumberRoute("", "messages"),
+			wantErr: true,
+		},
+		{
+			name:    "empty edge",
+			route:   FlowRoute("flow", ""),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.route.URL(base, "v16.0")
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRoute) {
+					t.Errorf("expected ErrInvalidRoute, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("URL() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteAuth(t *testing.T) {
+	t.Parallel()
+	route := QRCodeRoute("1234", "")
+	request := &Request{
+		Context: &RequestContext{
+			BaseURL:    "https://graph.facebook.com",
+			ApiVersion: "v16.0",
+			Route:      route.WithAuth(AuthQuery),
+		},
+		Method: http.MethodGet,
+		Bearer: "token",
+		Query:  map[string]string{"fields": "code"},
+	}
+
+	req, err := NewRequestWithContext(context.TODO(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.URL.Query().Get("access_token"); got != "token" {
+		t.Errorf("access_token = %q, want %q", got, "token")
+	}
+
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("unexpected Authorization header %q", got)
+	}
+
+	if route.Auth != AuthBearer {
+		t.Errorf("WithAuth modified the original route")
+	}
+
+	request.Context.Route = route
+	if req, err = NewRequestWithContext(context.TODO(), request); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer token" || req.URL.Query().Has("access_token") {
+		t.Errorf("expected a bearer header only, got %q and %q", got, req.URL.RawQuery)
+	}
+}
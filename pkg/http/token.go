@@ -0,0 +1,151 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTokenRefreshWindow is how long before its expiry a cached token is refreshed.
+const DefaultTokenRefreshWindow = 5 * time.Minute
+
+var ErrEmptyToken = errors.New("token source returned an empty token")
+
+type (
+	// Token is an access token and the time it expires at. A zero Expiry means the token
+	// does not expire.
+	Token struct {
+		AccessToken string
+		Expiry      time.Time
+	}
+
+	// TokenSource supplies the access token of every request. It is consulted each time a
+	// request is made, so implementations that call the network should cache the token,
+	// see NewCachingTokenSource.
+	TokenSource interface {
+		Token(ctx context.Context) (*Token, error)
+	}
+
+	// TokenSourceFunc is a function that implements the TokenSource interface.
+	TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+	// StaticTokenSource is a TokenSource that always returns the same token.
+	StaticTokenSource string
+
+	// CachingTokenSource caches the token of another TokenSource and fetches a new one before
+	// the cached one expires. It is safe for concurrent use.
+	CachingTokenSource struct {
+		source  TokenSource
+		window  time.Duration
+		now     func() time.Time
+		mu      sync.Mutex
+		current *Token
+	}
+)
+
+// Token implements the TokenSource interface.
+func (fn TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return fn(ctx)
+}
+
+// Token implements the TokenSource interface.
+func (token StaticTokenSource) Token(_ context.Context) (*Token, error) {
+	return &Token{AccessToken: string(token)}, nil
+}
+
+// ExpiresWithin reports whether the token expires within d from now.
+func (token *Token) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !token.Expiry.IsZero() && !now.Add(d).Before(token.Expiry)
+}
+
+// NewCachingTokenSource returns a TokenSource that caches the tokens of source and refreshes
+// them window before they expire, DefaultTokenRefreshWindow if window is zero.
+func NewCachingTokenSource(source TokenSource, window time.Duration) *CachingTokenSource {
+	if window <= 0 {
+		window = DefaultTokenRefreshWindow
+	}
+
+	return &CachingTokenSource{source: source, window: window, now: time.Now}
+}
+
+// Token implements the TokenSource interface. When the refresh fails the cached token is
+// returned for as long as it has not expired.
+func (cache *CachingTokenSource) Token(ctx context.Context) (*Token, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	now := cache.now()
+	if cache.current != nil && !cache.current.ExpiresWithin(now, cache.window) {
+		return cache.current, nil
+	}
+
+	token, err := cache.source.Token(ctx)
+	if err == nil && (token == nil || token.AccessToken == "") {
+		err = ErrEmptyToken
+	}
+
+	if err != nil {
+		if cache.current != nil && !cache.current.ExpiresWithin(now, 0) {
+			return cache.current, nil
+		}
+
+		return nil, fmt.Errorf("refresh token: %w", err)
+	}
+
+	cache.current = token
+
+	return token, nil
+}
+
+// Invalidate drops the cached token so that the next call fetches a new one, for example
+// after the API rejected the token.
+func (cache *CachingTokenSource) Invalidate() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.current = nil
+}
+
+// bearerToken returns the token of the request: from its TokenSource, then from the
+// TokenSource of its context and last from its Bearer field.
+func (request *Request) bearerToken(ctx context.Context) (string, error) {
+	source := request.TokenSource
+	if source == nil && request.Context != nil {
+		source = request.Context.TokenSource
+	}
+
+	if source == nil {
+		return request.Bearer, nil
+	}
+
+	token, err := source.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("token source: %w", err)
+	}
+
+	if token == nil {
+		return "", nil
+	}
+
+	return token.AccessToken, nil
+}
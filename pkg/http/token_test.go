@@ -0,0 +1,102 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCachingTokenSource(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	failing := false
+	source := NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		if failing {
+			return nil, errors.New("unavailable")
+		}
+		calls++
+
+		return &Token{AccessToken: "token-" + strconv.Itoa(calls), Expiry: now.Add(time.Hour)}, nil
+	}), 10*time.Minute)
+	source.now = func() time.Time { return now }
+
+	steps := []struct {
+		elapsed time.Duration
+		failing bool
+		want    string
+		wantErr bool
+	}{
+		{elapsed: 0, want: "token-1"},
+		{elapsed: 30 * time.Minute, want: "token-1"},
+		{elapsed: 55 * time.Minute, failing: true, want: "token-1"}, // refresh fails, token still valid
+		{elapsed: 55 * time.Minute, want: "token-2"},                // refreshed ahead of expiry
+		{elapsed: 3 * time.Hour, failing: true, wantErr: true},
+	}
+
+	start := now
+	for i, step := range steps {
+		now = start.Add(step.elapsed)
+		failing = step.failing
+		token, err := source.Token(context.TODO())
+		if step.wantErr {
+			if err == nil {
+				t.Errorf("step %d: expected an error", i)
+			}
+
+			continue
+		}
+
+		if err != nil || token.AccessToken != step.want {
+			t.Errorf("step %d: got %v, %v, want %s", i, token, err, step.want)
+		}
+	}
+}
+
+func TestRequestTokenSource(t *testing.T) {
+	t.Parallel()
+	request := &Request{
+		Context: &RequestContext{BaseURL: "https://example.com", TokenSource: StaticTokenSource("context")},
+		Method:  "GET",
+		Bearer:  "static",
+	}
+
+	req, err := NewRequestWithContext(context.TODO(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer context" {
+		t.Errorf("Authorization = %q, want the token of the context token source", got)
+	}
+
+	request.TokenSource = StaticTokenSource("request")
+	if req, err = NewRequestWithContext(context.TODO(), request); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer request" {
+		t.Errorf("Authorization = %q, want the token of the request token source", got)
+	}
+}
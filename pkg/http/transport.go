@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeouts and limits of the transport returned by NewTransport. There is no timeout for
+// the whole call, which would cut large media downloads, use the context instead.
+const (
+	DefaultDialTimeout           = 10 * time.Second
+	DefaultKeepAlive             = 30 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 30 * time.Second
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultMaxIdleConns          = 100
+	DefaultMaxIdleConnsPerHost   = 32
+)
+
+// maxPooledBufferSize keeps the buffers of large responses out of the pool.
+const maxPooledBufferSize = 1 << 20
+
+// defaultHTTPClient is shared by the clients created without WithHTTPClient so that they
+// share their connections.
+var defaultHTTPClient = NewHTTPClient() //nolint:gochecknoglobals
+
+var bufferPool = sync.Pool{ //nolint:gochecknoglobals
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// NewTransport returns a transport tuned for the Graph API: connections are kept alive and
+// reused, HTTP/2 is used when available and connecting, the TLS handshake and waiting for
+// the response headers are bounded.
+func NewTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: DefaultDialTimeout, KeepAlive: DefaultKeepAlive}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// NewHTTPClient returns an http.Client that uses NewTransport. It is the default of NewClient.
+func NewHTTPClient() *http.Client {
+	return &http.Client{Transport: NewTransport()}
+}
+
+func getBuffer() *bytes.Buffer {
+	buf, _ := bufferPool.Get().(*bytes.Buffer)
+
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
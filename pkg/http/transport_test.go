@@ -0,0 +1,144 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// streamingBody reports whether it was read after being closed.
+type streamingBody struct {
+	io.Reader
+	closed bool
+	late   bool
+}
+
+func (body *streamingBody) Read(p []byte) (int, error) {
+	if body.closed {
+		body.late = true
+	}
+
+	return body.Reader.Read(p) //nolint:wrapcheck
+}
+
+func (body *streamingBody) Close() error {
+	body.closed = true
+
+	return nil
+}
+
+func TestClientStreamsRawResponses(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		bodyHooks  bool
+		wantStream bool
+	}{
+		{name: "no body hooks", bodyHooks: false, wantStream: true},
+		{name: "body hooks", bodyHooks: true, wantStream: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var (
+				body       *streamingBody
+				requestLen int
+			)
+			transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+				sent, _ := io.ReadAll(request.Body)
+				requestLen = len(sent)
+				body = &streamingBody{Reader: strings.NewReader("media content")}
+
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: body}, nil
+			})
+
+			headerHookSawBody := false
+			options := []ClientOption{
+				WithHTTPClient(&http.Client{Transport: transport}),
+				WithResponseHeaderHooks(func(_ context.Context, response *http.Response) error {
+					headerHookSawBody = response.Body != http.NoBody
+
+					return nil
+				}),
+				// a request hook that consumes the body, which must be replayed
+				WithRequestHooks(func(_ context.Context, request *http.Request) error {
+					_, err := io.Copy(io.Discard, request.Body)
+
+					return err //nolint:wrapcheck
+				}),
+			}
+			if tt.bodyHooks {
+				options = append(options, WithResponseHooks(func(context.Context, *http.Response) error { return nil }))
+			}
+			client := NewClient(options...)
+
+			var streamed bool
+			var got bytes.Buffer
+			decoder := RawResponseDecoder(func(response *http.Response) error {
+				streamed = response.Body == io.ReadCloser(body)
+				_, err := io.Copy(&got, response.Body)
+
+				return err //nolint:wrapcheck
+			})
+
+			request := &Request{
+				Context: &RequestContext{Name: "download", Route: URLRoute("https://example.com/media")},
+				Method:  http.MethodPost,
+				Payload: map[string]string{"id": "1"},
+			}
+			if err := client.DoWithDecoder(context.TODO(), request, decoder, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if streamed != tt.wantStream || got.String() != "media content" || body.late || !body.closed {
+				t.Errorf("streamed = %v, body = %q, read after close = %v, closed = %v",
+					streamed, got.String(), body.late, body.closed)
+			}
+
+			if headerHookSawBody {
+				t.Errorf("header hooks must not see the body")
+			}
+
+			if requestLen != len(`{"id":"1"}`) {
+				t.Errorf("sent %d bytes, the request body was not replayed", requestLen)
+			}
+		})
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Parallel()
+	transport := NewTransport()
+	if !transport.ForceAttemptHTTP2 || transport.ResponseHeaderTimeout == 0 || transport.TLSHandshakeTimeout == 0 ||
+		transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost {
+		t.Errorf("transport is not tuned: %+v", transport)
+	}
+
+	if NewClient().http == http.DefaultClient {
+		t.Errorf("NewClient uses http.DefaultClient")
+	}
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Usage headers returned by the Graph API.
+const (
+	BusinessUseCaseUsageHeader = "X-Business-Use-Case-Usage"
+	AppUsageHeader             = "X-App-Usage"
+	AdAccountUsageHeader       = "X-Ad-Account-Usage"
+)
+
+// UsageSource is the header a Usage was read from.
+type UsageSource string
+
+const (
+	UsageSourceApp             UsageSource = "app"
+	UsageSourceBusinessUseCase UsageSource = "business_use_case"
+	UsageSourceAdAccount       UsageSource = "ad_account"
+)
+
+type (
+	// UsageKey identifies a rate limit. BusinessID and Type, the use case such as
+	// whatsapp_business_messaging, are only set for business use case usage.
+	UsageKey struct {
+		Source     UsageSource
+		BusinessID string
+		Type       string
+	}
+
+	// Usage is how much of a rate limit has been used, in percent. The call is throttled
+	// when any of the values reaches 100. EstimatedTimeToRegainAccess is set when the
+	// limit has been reached.
+	Usage struct {
+		CallCount                   float64
+		TotalTime                   float64
+		TotalCPUTime                float64
+		EstimatedTimeToRegainAccess time.Duration
+		UpdatedAt                   time.Time
+	}
+
+	// UsageTracker keeps the latest usage reported by the API per rate limit. Install its
+	// ResponseHook on the client that makes the calls.
+	//
+	//	tracker := whttp.NewUsageTracker()
+	//	client := whttp.NewClient(whttp.WithResponseHeaderHooks(tracker.ResponseHook()))
+	UsageTracker struct {
+		mu    sync.RWMutex
+		usage map[UsageKey]Usage
+		now   func() time.Time
+	}
+
+	businessUseCaseUsage struct {
+		Type                        string  `json:"type"`
+		CallCount                   float64 `json:"call_count"`
+		TotalTime                   float64 `json:"total_time"`
+		TotalCPUTime                float64 `json:"total_cputime"`
+		EstimatedTimeToRegainAccess float64 `json:"estimated_time_to_regain_access"`
+	}
+
+	appUsage struct {
+		CallCount    float64 `json:"call_count"`
+		TotalTime    float64 `json:"total_time"`
+		TotalCPUTime float64 `json:"total_cputime"`
+	}
+
+	adAccountUsage struct {
+		Utilization       float64 `json:"acc_id_util_pct"`
+		ResetTimeDuration float64 `json:"reset_time_duration"`
+	}
+)
+
+// Max returns the highest of the usage percentages.
+func (usage Usage) Max() float64 {
+	return max(usage.CallCount, usage.TotalTime, usage.TotalCPUTime)
+}
+
+// RegainAccessAt returns when access is expected to be regained, the zero time when the
+// limit has not been reached.
+func (usage Usage) RegainAccessAt() time.Time {
+	if usage.EstimatedTimeToRegainAccess <= 0 {
+		return time.Time{}
+	}
+
+	return usage.UpdatedAt.Add(usage.EstimatedTimeToRegainAccess)
+}
+
+// NewUsageTracker returns an empty UsageTracker.
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{usage: map[UsageKey]Usage{}, now: time.Now}
+}
+
+// ResponseHook returns a ResponseHook that records the usage headers of every response.
+// Headers that cannot be parsed are ignored.
+func (tracker *UsageTracker) ResponseHook() ResponseHook {
+	return func(_ context.Context, response *http.Response) error {
+		_ = tracker.Observe(response.Header)
+
+		return nil
+	}
+}
+
+// Observe records the usage headers in header. It returns an error if one of them cannot
+// be parsed, the others are still recorded.
+func (tracker *UsageTracker) Observe(header http.Header) error {
+	now := tracker.now()
+	updates := map[UsageKey]Usage{}
+	var errs []error
+
+	if value := header.Get(BusinessUseCaseUsageHeader); value != "" {
+		var usage map[string][]businessUseCaseUsage
+		if err := json.Unmarshal([]byte(value), &usage); err != nil {
+			errs = append(errs, fmt.Errorf("parse %s: %w", BusinessUseCaseUsageHeader, err))
+		}
+
+		for businessID, useCases := range usage {
+			for _, useCase := range useCases {
+				key := UsageKey{Source: UsageSourceBusinessUseCase, BusinessID: businessID, Type: useCase.Type}
+				updates[key] = Usage{
+					CallCount:    useCase.CallCount,
+					TotalTime:    useCase.TotalTime,
+					TotalCPUTime: useCase.TotalCPUTime,
+					EstimatedTimeToRegainAccess: time.Duration(useCase.EstimatedTimeToRegainAccess *
+						float64(time.Minute)),
+					UpdatedAt: now,
+				}
+			}
+		}
+	}
+
+	if value := header.Get(AppUsageHeader); value != "" {
+		var usage appUsage
+		if err := json.Unmarshal([]byte(value), &usage); err != nil {
+			errs = append(errs, fmt.Errorf("parse %s: %w", AppUsageHeader, err))
+		} else {
+			updates[UsageKey{Source: UsageSourceApp}] = Usage{
+				CallCount:    usage.CallCount,
+				TotalTime:    usage.TotalTime,
+				TotalCPUTime: usage.TotalCPUTime,
+				UpdatedAt:    now,
+			}
+		}
+	}
+
+	if value := header.Get(AdAccountUsageHeader); value != "" {
+		var usage adAccountUsage
+		if err := json.Unmarshal([]byte(value), &usage); err != nil {
+			errs = append(errs, fmt.Errorf("parse %s: %w", AdAccountUsageHeader, err))
+		} else {
+			updates[UsageKey{Source: UsageSourceAdAccount}] = Usage{
+				CallCount:                   usage.Utilization,
+				EstimatedTimeToRegainAccess: time.Duration(usage.ResetTimeDuration * float64(time.Second)),
+				UpdatedAt:                   now,
+			}
+		}
+	}
+
+	if len(updates) > 0 {
+		tracker.mu.Lock()
+		for key, usage := range updates {
+			tracker.usage[key] = usage
+		}
+		tracker.mu.Unlock()
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("usage headers: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Usage returns the latest usage of the rate limit.
+func (tracker *UsageTracker) Usage(key UsageKey) (Usage, bool) {
+	tracker.mu.RLock()
+	defer tracker.mu.RUnlock()
+	usage, ok := tracker.usage[key]
+
+	return usage, ok
+}
+
+// Snapshot returns a copy of the latest usage of every rate limit.
+func (tracker *UsageTracker) Snapshot() map[UsageKey]Usage {
+	tracker.mu.RLock()
+	defer tracker.mu.RUnlock()
+	snapshot := make(map[UsageKey]Usage, len(tracker.usage))
+	for key, usage := range tracker.usage {
+		snapshot[key] = usage
+	}
+
+	return snapshot
+}
+
+// Highest returns the most used rate limit among the ones updated within maxAge, or all
+// of them when maxAge is zero. It returns false when there is none.
+func (tracker *UsageTracker) Highest(maxAge time.Duration) (UsageKey, Usage, bool) {
+	tracker.mu.RLock()
+	defer tracker.mu.RUnlock()
+
+	var (
+		highestKey UsageKey
+		highest    Usage
+		found      bool
+		now        = tracker.now()
+	)
+	for key, usage := range tracker.usage {
+		if maxAge > 0 && now.Sub(usage.UpdatedAt) > maxAge {
+			continue
+		}
+
+		if !found || usage.Max() > highest.Max() {
+			highestKey, highest, found = key, usage, true
+		}
+	}
+
+	return highestKey, highest, found
+}
+
+// RegainAccessAt returns the latest time at which access to a throttled rate limit is
+// expected to be regained, the zero time when none is throttled.
+func (tracker *UsageTracker) RegainAccessAt() time.Time {
+	tracker.mu.RLock()
+	defer tracker.mu.RUnlock()
+
+	var latest time.Time
+	for _, usage := range tracker.usage {
+		if at := usage.RegainAccessAt(); at.After(latest) {
+			latest = at
+		}
+	}
+
+	return latest
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUsageTracker(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewUsageTracker()
+	tracker.now = func() time.Time { return now }
+
+	header := http.Header{}
+	header.Set(BusinessUseCaseUsageHeader, `{"1234":[{"type":"whatsapp_business_messaging","call_count":80,`+
+		`"total_cputime":10,"total_time":20,"estimated_time_to_regain_access":0},`+
+		`{"type":"whatsapp_business_management","call_count":100,"total_cputime":5,"total_time":5,`+
+		`"estimated_time_to_regain_access":3}]}`)
+	header.Set(AppUsageHeader, `{"call_count":28,"total_time":25,"total_cputime":30}`)
+	header.Set(AdAccountUsageHeader, `not json`)
+
+	if err := tracker.Observe(header); err == nil {
+		t.Errorf("expected an error for the ad account usage header")
+	}
+
+	app, ok := tracker.Usage(UsageKey{Source: UsageSourceApp})
+	if !ok || app.CallCount != 28 || app.Max() != 30 {
+		t.Errorf("app usage = %+v, %v", app, ok)
+	}
+
+	key := UsageKey{Source: UsageSourceBusinessUseCase, BusinessID: "1234", Type: "whatsapp_business_management"}
+	management, ok := tracker.Usage(key)
+	if !ok || management.EstimatedTimeToRegainAccess != 3*time.Minute {
+		t.Errorf("management usage = %+v, %v", management, ok)
+	}
+
+	if got := tracker.RegainAccessAt(); !got.Equal(now.Add(3 * time.Minute)) {
+		t.Errorf("RegainAccessAt() = %v", got)
+	}
+
+	if highestKey, highest, ok := tracker.Highest(0); !ok || highestKey != key || highest.Max() != 100 {
+		t.Errorf("Highest() = %+v, %+v, %v", highestKey, highest, ok)
+	}
+
+	if len(tracker.Snapshot()) != 3 {
+		t.Errorf("expected three rate limits, got %d", len(tracker.Snapshot()))
+	}
+
+	// stale usage is ignored
+	now = now.Add(time.Hour)
+	if _, _, ok = tracker.Highest(time.Minute); ok {
+		t.Errorf("expected no recent usage")
+	}
+}
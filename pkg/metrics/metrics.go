@@ -0,0 +1,450 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package metrics records counters and histograms with the standard library only and
+// exposes them in the Prometheus text format and through expvar. The clients and the
+// webhooks listener use it for their instrumentation, see whatsapp.WithBaseClientMetrics,
+// whttp.WithMetrics and webhooks.WithMetrics:
+//
+//	registry := metrics.NewRegistry()
+//	base := whatsapp.NewBaseClient(whatsapp.WithBaseClientMetrics(registry))
+//	listener := webhooks.NewEventListener(webhooks.WithMetrics(registry))
+//	registry.Publish("whatsapp")
+//	http.Handle("/metrics", registry.Handler())
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the latency histograms.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10} //nolint:gochecknoglobals
+
+type (
+	// Registry holds the metrics. It is safe for concurrent use.
+	Registry struct {
+		mu      sync.RWMutex
+		metrics map[string]metric
+	}
+
+	metric interface {
+		writeText(w *bufio.Writer)
+		snapshot() map[string]any
+	}
+
+	// vec holds the series of a metric, one per combination of label values.
+	vec[T any] struct {
+		name   string
+		help   string
+		labels []string
+
+		mu     sync.RWMutex
+		series map[string]*T
+		values map[string][]string
+	}
+
+	// CounterVec is a counter partitioned by labels.
+	CounterVec struct {
+		vec[counter]
+	}
+
+	// HistogramVec is a histogram partitioned by labels.
+	HistogramVec struct {
+		vec[histogram]
+		buckets []float64
+	}
+
+	counter struct {
+		mu    sync.Mutex
+		value float64
+	}
+
+	histogram struct {
+		mu     sync.Mutex
+		counts []uint64
+		count  uint64
+		sum    float64
+	}
+)
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// Counter returns the counter called name, creating it with help and labels the first time.
+// It panics when name is already used by a histogram or by a counter with other labels,
+// like expvar.Publish does on reuse.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.metrics[name]; ok {
+		c, isCounter := m.(*CounterVec)
+		if !isCounter {
+			panic(fmt.Sprintf("metrics: %s is not a counter", name))
+		}
+		c.checkLabels(labels)
+
+		return c
+	}
+
+	c := &CounterVec{vec: newVec[counter](name, help, labels)}
+	r.metrics[name] = c
+
+	return c
+}
+
+// Histogram returns the histogram called name, creating it with help, the upper bounds of
+// its buckets and labels the first time. DefaultBuckets are used when buckets is empty. It
+// panics when name is already used by a counter or by a histogram with other labels.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.metrics[name]; ok {
+		h, isHistogram := m.(*HistogramVec)
+		if !isHistogram {
+			panic(fmt.Sprintf("metrics: %s is not a histogram", name))
+		}
+		h.checkLabels(labels)
+
+		return h
+	}
+
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+
+	h := &HistogramVec{vec: newVec[histogram](name, help, labels), buckets: bounds}
+	r.metrics[name] = h
+
+	return h
+}
+
+// WriteText writes the metrics in the Prometheus text format, sorted by name.
+func (r *Registry) WriteText(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	for _, m := range r.sorted() {
+		m.writeText(buf)
+	}
+
+	return buf.Flush() //nolint:wrapcheck
+}
+
+// Handler returns a http.Handler that serves the metrics in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteText(w)
+	})
+}
+
+// Snapshot returns the current values of the metrics by name and then by series, a series
+// being the label values joined by commas. A counter series is a float64 and a histogram
+// series a map with the count, the sum and the cumulative count of every bucket.
+func (r *Registry) Snapshot() map[string]any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string]any, len(r.metrics))
+	for name, m := range r.metrics {
+		snapshot[name] = m.snapshot()
+	}
+
+	return snapshot
+}
+
+// Publish exposes the Snapshot of the registry through expvar under name. It does nothing
+// when name is already published.
+func (r *Registry) Publish(name string) {
+	if expvar.Get(name) != nil {
+		return
+	}
+
+	expvar.Publish(name, expvar.Func(func() any { return r.Snapshot() }))
+}
+
+func (r *Registry) sorted() []metric {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]metric, len(names))
+	for i, name := range names {
+		sorted[i] = r.metrics[name]
+	}
+
+	return sorted
+}
+
+// Inc adds one to the series of the label values.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds value, which must not be negative, to the series of the label values. Missing
+// label values are empty and extra ones are ignored.
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+
+	s := c.get(labelValues, func() *counter { return &counter{} })
+	s.mu.Lock()
+	s.value += value
+	s.mu.Unlock()
+}
+
+// Value returns the value of the series of the label values.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	s, ok := c.lookup(labelValues)
+	if !ok {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.value
+}
+
+func (c *CounterVec) writeText(w *bufio.Writer) {
+	c.writeHeader(w, "counter")
+	c.each(func(values []string, s *counter) {
+		s.mu.Lock()
+		value := s.value
+		s.mu.Unlock()
+
+		writeSample(w, c.name, c.labels, values, "", "", value)
+	})
+}
+
+func (c *CounterVec) snapshot() map[string]any {
+	snapshot := make(map[string]any)
+	c.each(func(values []string, s *counter) {
+		s.mu.Lock()
+		snapshot[strings.Join(values, ",")] = s.value
+		s.mu.Unlock()
+	})
+
+	return snapshot
+}
+
+// Observe records value in the series of the label values.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	s := h.get(labelValues, func() *histogram { return &histogram{counts: make([]uint64, len(h.buckets))} })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+// Count returns the number of values observed in the series of the label values.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	s, ok := h.lookup(labelValues)
+	if !ok {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.count
+}
+
+func (h *HistogramVec) writeText(w *bufio.Writer) {
+	h.writeHeader(w, "histogram")
+	h.each(func(values []string, s *histogram) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for i, bound := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labels, values, "le", formatFloat(bound), float64(s.counts[i]))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, values, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, values, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, values, "", "", float64(s.count))
+	})
+}
+
+func (h *HistogramVec) snapshot() map[string]any {
+	snapshot := make(map[string]any)
+	h.each(func(values []string, s *histogram) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		buckets := make(map[string]uint64, len(h.buckets))
+		for i, bound := range h.buckets {
+			buckets[formatFloat(bound)] = s.counts[i]
+		}
+		snapshot[strings.Join(values, ",")] = map[string]any{"count": s.count, "sum": s.sum, "buckets": buckets}
+	})
+
+	return snapshot
+}
+
+func newVec[T any](name, help string, labels []string) vec[T] {
+	return vec[T]{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+	}
+}
+
+// checkLabels panics when labels are not the labels of the metric, the series recorded under
+// other labels would be mixed up.
+func (v *vec[T]) checkLabels(labels []string) {
+	if !slices.Equal(v.labels, labels) {
+		panic(fmt.Sprintf("metrics: %s has the labels %v, not %v", v.name, v.labels, labels))
+	}
+}
+
+// key returns the label values padded or cut to the labels and the key of their series.
+func (v *vec[T]) key(labelValues []string) ([]string, string) {
+	values := make([]string, len(v.labels))
+	copy(values, labelValues)
+
+	return values, strings.Join(values, "\xff")
+}
+
+func (v *vec[T]) lookup(labelValues []string) (*T, bool) {
+	_, key := v.key(labelValues)
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	s, ok := v.series[key]
+
+	return s, ok
+}
+
+func (v *vec[T]) get(labelValues []string, create func() *T) *T {
+	values, key := v.key(labelValues)
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok = v.series[key]; !ok {
+		s = create()
+		v.series[key] = s
+		v.values[key] = values
+	}
+
+	return s
+}
+
+// each calls fn for every series in the order of their label values.
+func (v *vec[T]) each(fn func(values []string, s *T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	series, values := make([]*T, len(keys)), make([][]string, len(keys))
+	sort.Strings(keys)
+	for i, key := range keys {
+		series[i], values[i] = v.series[key], v.values[key]
+	}
+	v.mu.RUnlock()
+
+	for i := range series {
+		fn(values[i], series[i])
+	}
+}
+
+func (v *vec[T]) writeHeader(w *bufio.Writer, kind string) {
+	if v.help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", v.name, helpReplacer.Replace(v.help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, kind)
+}
+
+//nolint:gochecknoglobals
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// writeSample writes a sample line, with the extra label when extraName is not empty.
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string,
+	value float64,
+) {
+	w.WriteString(name)
+
+	pairs := make([]string, 0, len(labels)+1)
+	for i, label := range labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, labelReplacer.Replace(values[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+	if len(pairs) > 0 {
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
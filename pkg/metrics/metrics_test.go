@@ -0,0 +1,156 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	t.Parallel()
+	registry := NewRegistry()
+	requests := registry.Counter("requests_total", "Requests\nsent.", "operation", "status")
+	requests.Inc("send message", "200")
+	requests.Add(2, "send message", "200")
+	requests.Inc(`say "hi"`)
+	durations := registry.Histogram("duration_seconds", "Durations.", []float64{1, 0.1}, "operation")
+	durations.Observe(0.05, "send message")
+	durations.Observe(0.5, "send message")
+
+	if registry.Counter("requests_total", "", "operation", "status") != requests {
+		t.Errorf("Counter() created a second counter with the same name")
+	}
+
+	var b strings.Builder
+	if err := registry.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{operation="send message",le="0.1"} 1
+duration_seconds_bucket{operation="send message",le="1"} 2
+duration_seconds_bucket{operation="send message",le="+Inf"} 2
+duration_seconds_sum{operation="send message"} 0.55
+duration_seconds_count{operation="send message"} 2
+# HELP requests_total Requests\nsent.
+# TYPE requests_total counter
+requests_total{operation="say \"hi\"",status=""} 1
+requests_total{operation="send message",status="200"} 3
+`
+	if b.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRegistryHandler(t *testing.T) {
+	t.Parallel()
+	registry := NewRegistry()
+	registry.Counter("events_total", "Events.").Inc()
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if recorder.Header().Get("Content-Type") != ContentType || !strings.Contains(recorder.Body.String(),
+		"events_total 1\n") {
+		t.Errorf("Handler() served %q, %s", recorder.Header().Get("Content-Type"), recorder.Body.String())
+	}
+}
+
+func TestRegistryPublish(t *testing.T) {
+	t.Parallel()
+	registry := NewRegistry()
+	registry.Counter("events_total", "Events.", "kind").Inc("retry")
+	registry.Publish("metrics_test_registry")
+	registry.Publish("metrics_test_registry")
+
+	published := expvar.Get("metrics_test_registry")
+	if published == nil || !strings.Contains(published.String(), `"events_total":{"retry":1}`) {
+		t.Errorf("published %v", published)
+	}
+}
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	t.Parallel()
+	registry := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				registry.Counter("calls_total", "Calls.", "operation").Inc("send")
+				registry.Histogram("call_seconds", "Calls.", nil, "operation").Observe(0.01, "send")
+				_ = registry.WriteText(&strings.Builder{})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := registry.Counter("calls_total", "", "operation").Value("send"); got != 800 {
+		t.Errorf("Value() = %v, want 800", got)
+	}
+
+	if got := registry.Histogram("call_seconds", "", nil, "operation").Count("send"); got != 800 {
+		t.Errorf("Count() = %v, want 800", got)
+	}
+}
+
+func TestRegistryPanics(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		register func(registry *Registry)
+	}{
+		{
+			name:     "counter with other labels",
+			register: func(registry *Registry) { registry.Counter("calls_total", "", "status") },
+		},
+		{
+			name:     "histogram with other labels",
+			register: func(registry *Registry) { registry.Histogram("call_seconds", "", nil) },
+		},
+		{
+			name:     "counter registered as a histogram",
+			register: func(registry *Registry) { registry.Histogram("calls_total", "", nil, "operation") },
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			registry := NewRegistry()
+			registry.Counter("calls_total", "Calls.", "operation")
+			registry.Histogram("call_seconds", "Calls.", nil, "operation")
+
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic")
+				}
+			}()
+			tt.register(registry)
+		})
+	}
+}
@@ -58,7 +58,7 @@ type (
 	}
 
 	// MediaInfo provides information about a media be it an Audio, Video, etc.
-	// Animated used with stickers only.
+	// Animated used with stickers only and Voice with audio only.
 	MediaInfo struct {
 		ID       string `json:"id,omitempty"`
 		Caption  string `json:"caption,omitempty"`
@@ -66,6 +66,7 @@ type (
 		Sha256   string `json:"sha256,omitempty"`
 		Filename string `json:"filename,omitempty"`
 		Animated bool   `json:"animated,omitempty"` // used with stickers true if animated
+		Voice    bool   `json:"voice,omitempty"`    // used with audio true if a voice note
 	}
 
 	// Media represents a media object. This object is used to send media messages to WhatsApp users.
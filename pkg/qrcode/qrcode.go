@@ -0,0 +1,651 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package qrcode encodes text such as WhatsApp deep links into QR codes and renders them
+// as PNG or SVG images without calling the QR code endpoints of the Cloud API.
+//
+// Data is always encoded in byte mode, which covers every URL. The smallest version (1-40)
+// that fits the data at the requested error correction level is used.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Level is the error correction level of a QR code. Higher levels can be read when a bigger
+// part of the code is damaged or covered, for example by a logo, at the cost of a bigger code.
+type Level int
+
+const (
+	Low      Level = iota // recovers about 7% of the codewords
+	Medium                // recovers about 15% of the codewords
+	Quartile              // recovers about 25% of the codewords
+	High                  // recovers about 30% of the codewords
+)
+
+const (
+	MinVersion = 1
+	MaxVersion = 40
+)
+
+var ErrDataTooLong = errors.New("data too long for a qr code")
+
+// String returns the letter used for the level in the QR code specification.
+func (level Level) String() string {
+	switch level {
+	case Low:
+		return "L"
+	case Medium:
+		return "M"
+	case Quartile:
+		return "Q"
+	case High:
+		return "H"
+	default:
+		return fmt.Sprintf("Level(%d)", int(level))
+	}
+}
+
+// formatBits returns the two bits identifying the level in the format information.
+func (level Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[level]
+}
+
+//nolint:gochecknoglobals
+var (
+	// eccCodewordsPerBlock is indexed by level and version.
+	eccCodewordsPerBlock = [4][41]int{
+		{
+			-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28,
+			28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+		},
+		{
+			-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26,
+			26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+		},
+		{
+			-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30,
+			28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+		},
+		{
+			-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28,
+			30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+		},
+	}
+
+	// numErrorCorrectionBlocks is indexed by level and version.
+	numErrorCorrectionBlocks = [4][41]int{
+		{
+			-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8,
+			8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25,
+		},
+		{
+			-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+			17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49,
+		},
+		{
+			-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20,
+			23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68,
+		},
+		{
+			-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25,
+			25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81,
+		},
+	}
+)
+
+// Code is an encoded QR code. Modules are addressed by x (column) and y (row) starting
+// from the top left corner, the quiet zone around the code is not included.
+type Code struct {
+	Version int
+	Level   Level
+	Mask    int
+	size    int
+	modules [][]bool
+	isFunc  [][]bool
+}
+
+// Encode encodes data into a QR code with the given error correction level.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, fmt.Errorf("qrcode: invalid error correction level %d", level)
+	}
+
+	version := MinVersion
+	for ; version <= MaxVersion; version++ {
+		if byteModeBits(version, len(data)) <= numDataCodewords(version, level)*8 {
+			break
+		}
+	}
+
+	if version > MaxVersion {
+		return nil, fmt.Errorf("%w: %d bytes at level %s", ErrDataTooLong, len(data), level)
+	}
+
+	codewords := encodeData(data, version, level)
+	code := newCode(version, level)
+	code.drawCodewords(code.addECCAndInterleave(codewords))
+	code.applyBestMask()
+
+	return code, nil
+}
+
+// EncodeString encodes s into a QR code with the given error correction level.
+func EncodeString(s string, level Level) (*Code, error) {
+	return Encode([]byte(s), level)
+}
+
+// Size returns the number of modules on each side of the code.
+func (code *Code) Size() int {
+	return code.size
+}
+
+// Dark reports whether the module at column x and row y is dark. Modules outside the code
+// are light.
+func (code *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= code.size || y >= code.size {
+		return false
+	}
+
+	return code.modules[y][x]
+}
+
+func byteModeBits(version, length int) int {
+	countBits := 8
+	if version >= 10 { //nolint:gomnd
+		countBits = 16
+	}
+
+	if length >= 1<<countBits {
+		return math.MaxInt32
+	}
+
+	return 4 + countBits + length*8 //nolint:gomnd
+}
+
+// numRawDataModules returns the number of modules that can hold data and error correction
+// codewords in a code of the given version.
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64 //nolint:gomnd
+	if version >= 2 {                       //nolint:gomnd
+		numAlign := version/7 + 2                //nolint:gomnd
+		result -= (25*numAlign-10)*numAlign - 55 //nolint:gomnd
+		if version >= 7 {                        //nolint:gomnd
+			result -= 36 //nolint:gomnd
+		}
+	}
+
+	return result
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 -
+		eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// encodeData returns the data codewords: the byte mode segment followed by the terminator
+// and padding.
+func encodeData(data []byte, version int, level Level) []byte {
+	capacity := numDataCodewords(version, level) * 8
+	bits := &bitBuffer{}
+
+	countBits := 8
+	if version >= 10 { //nolint:gomnd
+		countBits = 16
+	}
+
+	bits.append(0b0100, 4) //nolint:gomnd
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8) //nolint:gomnd
+	}
+
+	bits.append(0, min(4, capacity-bits.len())) //nolint:gomnd
+	bits.append(0, (8-bits.len()%8)%8)          //nolint:gomnd
+
+	for pad := 0xEC; bits.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8) //nolint:gomnd
+	}
+
+	return bits.bytes()
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (buf *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		buf.bits = append(buf.bits, (value>>i)&1 == 1)
+	}
+}
+
+func (buf *bitBuffer) len() int {
+	return len(buf.bits)
+}
+
+func (buf *bitBuffer) bytes() []byte {
+	result := make([]byte, len(buf.bits)/8) //nolint:gomnd
+	for i, bit := range buf.bits {
+		if bit {
+			result[i>>3] |= 1 << (7 - i&7) //nolint:gomnd
+		}
+	}
+
+	return result
+}
+
+func newCode(version int, level Level) *Code {
+	size := version*4 + 17 //nolint:gomnd
+	code := &Code{
+		Version: version,
+		Level:   level,
+		size:    size,
+		modules: make([][]bool, size),
+		isFunc:  make([][]bool, size),
+	}
+
+	for i := range code.modules {
+		code.modules[i] = make([]bool, size)
+		code.isFunc[i] = make([]bool, size)
+	}
+
+	code.drawFunctionPatterns()
+
+	return code
+}
+
+func (code *Code) setFunction(x, y int, dark bool) {
+	code.modules[y][x] = dark
+	code.isFunc[y][x] = true
+}
+
+func (code *Code) drawFunctionPatterns() {
+	for i := 0; i < code.size; i++ {
+		code.setFunction(6, i, i%2 == 0)
+		code.setFunction(i, 6, i%2 == 0)
+	}
+
+	code.drawFinderPattern(3, 3)
+	code.drawFinderPattern(code.size-4, 3)
+	code.drawFinderPattern(3, code.size-4)
+
+	positions := alignmentPatternPositions(code.Version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// skip the three corners with finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			code.drawAlignmentPattern(x, y)
+		}
+	}
+
+	code.drawFormatBits(0)
+	code.drawVersion()
+}
+
+func (code *Code) drawFinderPattern(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= code.size || y >= code.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			code.setFunction(x, y, dist != 2 && dist != 4) //nolint:gomnd
+		}
+	}
+}
+
+func (code *Code) drawAlignmentPattern(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			code.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the format information for the level and mask, and
+// the dark module.
+func (code *Code) drawFormatBits(mask int) {
+	data := code.Level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537) //nolint:gomnd
+	}
+	bits := (data<<10 | rem) ^ 0x5412 //nolint:gomnd
+
+	for i := 0; i <= 5; i++ {
+		code.setFunction(8, i, bit(bits, i))
+	}
+	code.setFunction(8, 7, bit(bits, 6))
+	code.setFunction(8, 8, bit(bits, 7))
+	code.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		code.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		code.setFunction(code.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		code.setFunction(8, code.size-15+i, bit(bits, i))
+	}
+	code.setFunction(8, code.size-8, true)
+}
+
+// drawVersion draws both copies of the version information, only present from version 7.
+func (code *Code) drawVersion() {
+	if code.Version < 7 { //nolint:gomnd
+		return
+	}
+
+	rem := code.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25) //nolint:gomnd
+	}
+	bits := code.Version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a, b := code.size-11+i%3, i/3
+		code.setFunction(a, b, bit(bits, i))
+		code.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// alignmentPatternPositions returns the row and column coordinates of the centers of the
+// alignment patterns for the version.
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	numAlign := version/7 + 2                                   //nolint:gomnd
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2 //nolint:gomnd
+	positions := make([]int, numAlign)
+	positions[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+
+	return positions
+}
+
+// addECCAndInterleave splits the data codewords into blocks, appends the Reed-Solomon
+// error correction codewords to each block and interleaves them.
+func (code *Code) addECCAndInterleave(data []byte) []byte {
+	numBlocks := numErrorCorrectionBlocks[code.Level][code.Version]
+	blockECCLen := eccCodewordsPerBlock[code.Level][code.Version]
+	rawCodewords := numRawDataModules(code.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		length := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			length++
+		}
+
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, data[k:k+length]...)
+		k += length
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := 0; i < len(blocks[0]); i++ {
+		for j, block := range blocks {
+			// short blocks have a placeholder byte where long blocks have their last data byte
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	return result
+}
+
+// drawCodewords places the codewords in the zigzag pattern, two columns at a time from the
+// bottom right corner, skipping function modules.
+func (code *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := code.size - 1; right >= 1; right -= 2 {
+		if right == 6 { //nolint:gomnd
+			right = 5
+		}
+
+		for vert := 0; vert < code.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = code.size - 1 - vert
+				}
+
+				if !code.isFunc[y][x] && i < len(data)*8 {
+					code.modules[y][x] = bit(int(data[i>>3]), 7-(i&7)) //nolint:gomnd
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by the mask pattern. Applying the same mask
+// twice undoes it.
+func (code *Code) applyMask(mask int) {
+	for y := 0; y < code.size; y++ {
+		for x := 0; x < code.size; x++ {
+			if !code.isFunc[y][x] && maskAt(mask, x, y) {
+				code.modules[y][x] = !code.modules[y][x]
+			}
+		}
+	}
+}
+
+//nolint:gomnd
+func maskAt(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyBestMask tries the eight mask patterns and keeps the one with the lowest penalty.
+func (code *Code) applyBestMask() {
+	best, bestPenalty := 0, math.MaxInt
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(mask)
+		if penalty := code.penalty(); penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		code.applyMask(mask)
+	}
+
+	code.Mask = best
+	code.applyMask(best)
+	code.drawFormatBits(best)
+}
+
+// penalty scores the code using the four rules of the QR code specification, lower is better.
+//
+//nolint:gomnd,cyclop
+func (code *Code) penalty() int {
+	result := 0
+	size := code.size
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return code.modules[x][y]
+		}
+
+		return code.modules[y][x]
+	}
+
+	finderLike := [...]bool{true, false, true, true, true, false, true}
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < size; y++ {
+			run := 1
+			for x := 1; x <= size; x++ {
+				if x < size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+
+			for x := 0; x+len(finderLike) <= size; x++ {
+				match := true
+				for i, dark := range finderLike {
+					if at(x+i, y, vertical) != dark {
+						match = false
+
+						break
+					}
+				}
+
+				if match && (lightRun(code, x-4, x, y, vertical) || lightRun(code, x+7, x+11, y, vertical)) {
+					result += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if code.modules[y][x] {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				c := code.modules[y][x]
+				if c == code.modules[y][x+1] && c == code.modules[y+1][x] && c == code.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+
+	total := size * size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * 10
+
+	return result
+}
+
+// lightRun reports whether the modules from start up to end (exclusive) on the line are all
+// light. Modules outside the code count as light, like the quiet zone.
+func lightRun(code *Code, start, end, line int, vertical bool) bool {
+	for i := start; i < end; i++ {
+		if i < 0 || i >= code.size {
+			continue
+		}
+		if (vertical && code.modules[i][line]) || (!vertical && code.modules[line][i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reedSolomonDivisor returns the generator polynomial of the given degree, without the
+// leading term, with coefficients from the highest to the lowest power.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02) //nolint:gomnd
+	}
+
+	return result
+}
+
+// reedSolomonRemainder returns the error correction codewords of data.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+
+	return result
+}
+
+// gfMultiply multiplies two elements of GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D) //nolint:gomnd
+		z ^= int((y>>i)&1) * int(x)
+	}
+
+	return byte(z)
+}
+
+func bit(value, i int) bool {
+	return (value>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -0,0 +1,308 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestEncodeCapacity(t *testing.T) {
+	t.Parallel()
+	// byte mode capacities from the QR code specification
+	tests := []struct {
+		level    Level
+		version  int
+		capacity int
+	}{
+		{level: Low, version: 1, capacity: 17},
+		{level: Medium, version: 1, capacity: 14},
+		{level: Quartile, version: 1, capacity: 11},
+		{level: High, version: 1, capacity: 7},
+		{level: Medium, version: 10, capacity: 213},
+		{level: Low, version: 40, capacity: 2953},
+		{level: High, version: 40, capacity: 1273},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.level.String(), func(t *testing.T) {
+			t.Parallel()
+			code, err := Encode(bytes.Repeat([]byte("a"), tt.capacity), tt.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if code.Version != tt.version {
+				t.Errorf("version = %d, want %d", code.Version, tt.version)
+			}
+
+			if tt.version == MaxVersion {
+				_, err = Encode(bytes.Repeat([]byte("a"), tt.capacity+1), tt.level)
+				if !errors.Is(err, ErrDataTooLong) {
+					t.Errorf("expected ErrDataTooLong, got %v", err)
+				}
+			} else if code, _ = Encode(bytes.Repeat([]byte("a"), tt.capacity+1), tt.level); code.Version != tt.version+1 {
+				t.Errorf("version for capacity+1 = %d, want %d", code.Version, tt.version+1)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+	inputs := []string{
+		"",
+		"https://wa.me/255700000000",
+		"https://wa.me/255700000000?text=" + strings.Repeat("Habari%20yako%3F", 20),
+		strings.Repeat("0123456789abcdef", 75),
+	}
+
+	for _, input := range inputs {
+		for level := Low; level <= High; level++ {
+			code, err := EncodeString(input, level)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := decode(t, code); got != input {
+				t.Errorf("decoded %q at level %s, want %q", got, level, input)
+			}
+		}
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	t.Parallel()
+	// level M with mask 0 is 101010000010010 in the specification
+	code := newCode(1, Medium)
+	code.drawFormatBits(0)
+	want := 0b101010000010010
+
+	for i := 0; i < 8; i++ {
+		if code.Dark(code.size-1-i, 8) != bit(want, i) {
+			t.Fatalf("format bit %d does not match", i)
+		}
+	}
+}
+
+func TestRenderLevel(t *testing.T) {
+	t.Parallel()
+	// 16 bytes fit a version 1 code at Low but need version 2 at Medium.
+	link := []byte("https://wa.me/25")
+
+	tests := []struct {
+		name    string
+		options *Options
+		want    int
+	}{
+		{name: "nil options", options: nil, want: 21},
+		{name: "zero level", options: &Options{}, want: 21},
+		{name: "medium", options: &Options{Level: Medium}, want: 25},
+		{name: "logo", options: &Options{Logo: image.NewRGBA(image.Rect(0, 0, 4, 4))}, want: 29},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			if err := SVG(&buf, link, tt.options); err != nil {
+				t.Fatal(err)
+			}
+
+			modules := tt.want + 2*DefaultMargin
+			if want := fmt.Sprintf(`viewBox="0 0 %d %d"`, modules, modules); !strings.Contains(buf.String(), want) {
+				t.Errorf("svg does not contain %q", want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+	link := []byte("https://wa.me/255700000000?text=Hello")
+
+	var buf bytes.Buffer
+	if err := PNG(&buf, link, &Options{Size: 300, Margin: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := Encode(link, Low)
+	modules := code.Size() + 4
+	scale := 300 / modules
+	if img.Bounds().Dx() != modules*scale {
+		t.Errorf("width = %d, want %d", img.Bounds().Dx(), modules*scale)
+	}
+
+	// the top left corner of the finder pattern is dark and the margin is light
+	if r, _, _, _ := img.At(2*scale, 2*scale).RGBA(); r != 0 {
+		t.Errorf("expected a dark finder pattern module")
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Errorf("expected a light margin")
+	}
+
+	logo := image.NewUniform(color.RGBA{R: 0x25, G: 0xD3, B: 0x66, A: 0xFF})
+	buf.Reset()
+	if err = SVG(&buf, link, &Options{Logo: image.NewRGBA(image.Rect(0, 0, 4, 4)), Foreground: logo.C}); err != nil {
+		t.Fatal(err)
+	}
+
+	svg := buf.String()
+	for _, want := range []string{`viewBox="0 0 `, `fill="#25d366"`, `data:image/png;base64,`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("svg does not contain %q", want)
+		}
+	}
+}
+
+// decode reads the data back from the code, checking the format information and the
+// Reed-Solomon syndromes of every block on the way.
+func decode(t *testing.T, code *Code) string {
+	t.Helper()
+
+	format := 0
+	for i := 0; i < 8; i++ {
+		if code.Dark(code.size-1-i, 8) {
+			format |= 1 << i
+		}
+	}
+	for i := 8; i < 15; i++ {
+		if code.Dark(8, code.size-15+i) {
+			format |= 1 << i
+		}
+	}
+	format ^= 0x5412
+	if level, mask := format>>13, (format>>10)&7; level != code.Level.formatBits() || mask != code.Mask {
+		t.Fatalf("format information has level %d and mask %d", level, mask)
+	}
+
+	var stream []byte
+	var current byte
+	count := 0
+	for right := code.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < code.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = code.size - 1 - vert
+				}
+				if code.isFunc[y][x] {
+					continue
+				}
+				dark := code.modules[y][x] != maskAt(code.Mask, x, y)
+				current <<= 1
+				if dark {
+					current |= 1
+				}
+				if count++; count%8 == 0 {
+					stream = append(stream, current)
+				}
+			}
+		}
+	}
+
+	numBlocks := numErrorCorrectionBlocks[code.Level][code.Version]
+	eccLen := eccCodewordsPerBlock[code.Level][code.Version]
+	raw := numRawDataModules(code.Version) / 8
+	stream = stream[:raw]
+	numShort := numBlocks - raw%numBlocks
+	shortData := raw/numBlocks - eccLen
+
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i < shortData+1; i++ {
+		for b := range blocks {
+			if i < shortData || b >= numShort {
+				blocks[b] = append(blocks[b], stream[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], stream[k])
+			k++
+		}
+	}
+
+	var data []byte
+	for b, block := range blocks {
+		root := byte(1)
+		for i := 0; i < eccLen; i++ {
+			var syndrome byte
+			for _, c := range block {
+				syndrome = gfMultiply(syndrome, root) ^ c
+			}
+			if syndrome != 0 {
+				t.Fatalf("block %d has a non zero syndrome", b)
+			}
+			root = gfMultiply(root, 2)
+		}
+		data = append(data, block[:len(block)-eccLen]...)
+	}
+
+	if data[0]>>4 != 0b0100 {
+		t.Fatalf("unexpected mode %04b", data[0]>>4)
+	}
+
+	bits := &bitBuffer{}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	read := func(offset, length int) int {
+		value := 0
+		for i := offset; i < offset+length; i++ {
+			value <<= 1
+			if bits.bits[i] {
+				value |= 1
+			}
+		}
+
+		return value
+	}
+
+	countBits := 8
+	if code.Version >= 10 {
+		countBits = 16
+	}
+	length := read(4, countBits)
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = byte(read(4+countBits+i*8, 8))
+	}
+
+	return string(result)
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package qrcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+)
+
+const (
+	// DefaultMargin is the width of the quiet zone in modules recommended by the specification.
+	DefaultMargin = 4
+
+	// DefaultSize is the default width and height of PNG images in pixels.
+	DefaultSize = 512
+
+	// DefaultLogoSize is the default width of the logo as a fraction of the code width.
+	DefaultLogoSize = 0.2
+
+	// maxLogoSize keeps the logo small enough for level H to recover the covered modules.
+	maxLogoSize = 0.3
+)
+
+// Options configures how a QR code is encoded and rendered. The zero value renders a
+// Low level code with the default margin, size and black on white colors.
+type Options struct {
+	// Level is the error correction level, Low when zero. It is raised to High when a Logo is set.
+	Level Level
+
+	// Margin is the quiet zone around the code in modules. Zero means DefaultMargin and a
+	// negative value means no margin.
+	Margin int
+
+	// Size is the width and height of PNG images in pixels. The image is never smaller than
+	// one pixel per module. SVG images are scalable and use Size only as their nominal size.
+	Size int
+
+	// Foreground and Background are the colors of the dark and light modules.
+	Foreground color.Color
+	Background color.Color
+
+	// Logo is an optional image drawn at the center of the code.
+	Logo image.Image
+
+	// LogoSize is the width of the logo as a fraction of the code width, without the margin.
+	// Zero means DefaultLogoSize, values are capped at 0.3.
+	LogoSize float64
+}
+
+func (opts *Options) withDefaults() Options {
+	result := Options{}
+	if opts != nil {
+		result = *opts
+	}
+
+	if result.Margin == 0 {
+		result.Margin = DefaultMargin
+	} else if result.Margin < 0 {
+		result.Margin = 0
+	}
+
+	if result.Size <= 0 {
+		result.Size = DefaultSize
+	}
+
+	if result.Foreground == nil {
+		result.Foreground = color.Black
+	}
+
+	if result.Background == nil {
+		result.Background = color.White
+	}
+
+	if result.Logo != nil {
+		result.Level = High
+		if result.LogoSize <= 0 {
+			result.LogoSize = DefaultLogoSize
+		}
+		result.LogoSize = min(result.LogoSize, maxLogoSize)
+	}
+
+	return result
+}
+
+// PNG encodes data and writes it to w as a PNG image.
+func PNG(w io.Writer, data []byte, opts *Options) error {
+	options := opts.withDefaults()
+	code, err := Encode(data, options.Level)
+	if err != nil {
+		return err
+	}
+
+	return code.WritePNG(w, &options)
+}
+
+// SVG encodes data and writes it to w as an SVG image.
+func SVG(w io.Writer, data []byte, opts *Options) error {
+	options := opts.withDefaults()
+	code, err := Encode(data, options.Level)
+	if err != nil {
+		return err
+	}
+
+	return code.WriteSVG(w, &options)
+}
+
+// Image renders the code as an image. Each module is drawn as a square of whole pixels so
+// the edges stay sharp, which may make the image slightly smaller than opts.Size.
+func (code *Code) Image(opts *Options) image.Image {
+	options := opts.withDefaults()
+	modules := code.size + 2*options.Margin
+	scale := max(1, options.Size/modules)
+	width := modules * scale
+
+	img := image.NewRGBA(image.Rect(0, 0, width, width))
+	draw.Draw(img, img.Bounds(), image.NewUniform(options.Background), image.Point{}, draw.Src)
+
+	foreground := image.NewUniform(options.Foreground)
+	for y := 0; y < code.size; y++ {
+		for x := 0; x < code.size; x++ {
+			if !code.modules[y][x] {
+				continue
+			}
+			px, py := (x+options.Margin)*scale, (y+options.Margin)*scale
+			draw.Draw(img, image.Rect(px, py, px+scale, py+scale), foreground, image.Point{}, draw.Src)
+		}
+	}
+
+	if options.Logo != nil {
+		side := int(float64(code.size*scale) * options.LogoSize)
+		offset := (width - side) / 2 //nolint:gomnd
+		area := image.Rect(offset, offset, offset+side, offset+side)
+		drawScaled(img, area, options.Logo)
+	}
+
+	return img
+}
+
+// WritePNG writes the code to w as a PNG image.
+func (code *Code) WritePNG(w io.Writer, opts *Options) error {
+	if err := png.Encode(w, code.Image(opts)); err != nil {
+		return fmt.Errorf("qrcode: encode png: %w", err)
+	}
+
+	return nil
+}
+
+// WriteSVG writes the code to w as an SVG image. The dark modules are drawn as a single
+// path in a viewBox measured in modules, so the image scales to any print size.
+func (code *Code) WriteSVG(w io.Writer, opts *Options) error {
+	options := opts.withDefaults()
+	modules := code.size + 2*options.Margin
+
+	var path strings.Builder
+	for y := 0; y < code.size; y++ {
+		for x := 0; x < code.size; x++ {
+			if !code.modules[y][x] {
+				continue
+			}
+			// merge horizontal runs of dark modules into one rectangle
+			run := 1
+			for x+run < code.size && code.modules[y][x+run] {
+				run++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", x+options.Margin, y+options.Margin, run, run)
+			x += run - 1
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d" `+
+		`width="%d" height="%d" shape-rendering="crispEdges">`+"\n",
+		modules, modules, options.Size, options.Size)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", svgColor(options.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="%s"/>`+"\n", svgColor(options.Foreground), path.String())
+
+	if options.Logo != nil {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, options.Logo); err != nil {
+			return fmt.Errorf("qrcode: encode logo: %w", err)
+		}
+		side := float64(code.size) * options.LogoSize
+		offset := (float64(modules) - side) / 2 //nolint:gomnd
+		fmt.Fprintf(&buf, `<image x="%.3f" y="%.3f" width="%.3f" height="%.3f" `+
+			`href="data:image/png;base64,%s"/>`+"\n",
+			offset, offset, side, side, base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+
+	buf.WriteString("</svg>\n")
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("qrcode: write svg: %w", err)
+	}
+
+	return nil
+}
+
+// drawScaled draws src into the area of dst using nearest neighbour scaling.
+func drawScaled(dst draw.Image, area image.Rectangle, src image.Image) {
+	bounds := src.Bounds()
+	if bounds.Empty() || area.Empty() {
+		return
+	}
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		sy := bounds.Min.Y + (y-area.Min.Y)*bounds.Dy()/area.Dy()
+		for x := area.Min.X; x < area.Max.X; x++ {
+			sx := bounds.Min.X + (x-area.Min.X)*bounds.Dx()/area.Dx()
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+}
+
+func svgColor(c color.Color) string {
+	rgba := color.NRGBAModel.Convert(c).(color.NRGBA) //nolint:forcetypeassert
+	if rgba.A == 0xFF {                               //nolint:gomnd
+		return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+	}
+
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3f)", rgba.R, rgba.G, rgba.B, float64(rgba.A)/0xFF) //nolint:gomnd
+}
@@ -0,0 +1,390 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package redact removes secrets from what the library logs: access tokens, app secrets,
+// appsecret_proof and webhook signatures are always replaced with Redacted. Customer phone
+// numbers and message bodies are masked too when a Redactor is created with
+// MaskPhoneNumbers and MaskMessageBodies, and logged bodies are truncated.
+//
+// A nil *Redactor is valid, it redacts secrets and truncates bodies to DefaultMaxBodySize.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Redacted replaces the secrets and, when masked, the message bodies.
+	Redacted = "[REDACTED]"
+
+	// DefaultMaxBodySize is the number of bytes of a body that are logged by default.
+	DefaultMaxBodySize = 1024
+)
+
+//nolint:gochecknoglobals
+var (
+	// secretKeys are query parameters, form fields and JSON keys that hold secrets.
+	secretKeys = map[string]bool{
+		"access_token":      true,
+		"appsecret_proof":   true,
+		"app_secret":        true,
+		"client_secret":     true,
+		"fb_exchange_token": true,
+		"input_token":       true,
+		"token":             true,
+		"verify_token":      true,
+		"hub.verify_token":  true,
+		"password":          true,
+		"secret":            true,
+	}
+
+	// secretHeaders are the canonical names of the headers that hold secrets.
+	secretHeaders = map[string]bool{
+		"Authorization":       true,
+		"Proxy-Authorization": true,
+		"Cookie":              true,
+		"Set-Cookie":          true,
+		"X-Hub-Signature":     true,
+		"X-Hub-Signature-256": true,
+	}
+
+	// phoneKeys are the JSON keys that hold customer phone numbers or WhatsApp IDs.
+	phoneKeys = map[string]bool{
+		"to":           true,
+		"from":         true,
+		"wa_id":        true,
+		"new_wa_id":    true,
+		"recipient_id": true,
+		"input":        true,
+		"phone":        true,
+	}
+
+	// bodyKeys are the JSON keys that hold what customers and businesses write.
+	bodyKeys = map[string]bool{
+		"body":    true,
+		"caption": true,
+		"text":    true,
+	}
+)
+
+type (
+	// Redactor redacts headers, URLs, bodies and values before they are logged.
+	Redactor struct {
+		maskPhoneNumbers  bool
+		maskMessageBodies bool
+		maxBodySize       int
+	}
+
+	// Option configures a Redactor.
+	Option func(*Redactor)
+)
+
+// MaskPhoneNumbers masks customer phone numbers and WhatsApp IDs, only their last four
+// digits are kept.
+func MaskPhoneNumbers() Option {
+	return func(r *Redactor) {
+		r.maskPhoneNumbers = true
+	}
+}
+
+// MaskMessageBodies replaces the text of messages, captions and message bodies with Redacted.
+func MaskMessageBodies() Option {
+	return func(r *Redactor) {
+		r.maskMessageBodies = true
+	}
+}
+
+// MaxBodySize sets the number of bytes of a body that are logged, a negative size keeps
+// the whole body. The default is DefaultMaxBodySize.
+func MaxBodySize(size int) Option {
+	return func(r *Redactor) {
+		r.maxBodySize = size
+	}
+}
+
+// New creates a Redactor.
+func New(options ...Option) *Redactor {
+	r := &Redactor{maxBodySize: DefaultMaxBodySize}
+	for _, option := range options {
+		if option != nil {
+			option(r)
+		}
+	}
+
+	return r
+}
+
+// IsSecret reports whether a query parameter, form field or JSON key holds a secret.
+func IsSecret(key string) bool {
+	return secretKeys[strings.ToLower(key)]
+}
+
+// Header returns the value of the header key with the secret removed. The scheme of an
+// Authorization header is kept, so that "Bearer [REDACTED]" is logged.
+func (r *Redactor) Header(key, value string) string {
+	key = http.CanonicalHeaderKey(key)
+	if !secretHeaders[key] {
+		return value
+	}
+
+	if scheme, _, ok := strings.Cut(value, " "); ok && strings.HasSuffix(key, "Authorization") {
+		return scheme + " " + Redacted
+	}
+
+	return Redacted
+}
+
+// Headers returns a copy of headers with the secrets removed.
+func (r *Redactor) Headers(headers http.Header) http.Header {
+	redacted := make(http.Header, len(headers))
+	for key, values := range headers {
+		copied := make([]string, len(values))
+		for i, value := range values {
+			copied[i] = r.Header(key, value)
+		}
+		redacted[key] = copied
+	}
+
+	return redacted
+}
+
+// Query returns a copy of values with the secrets removed.
+func (r *Redactor) Query(values url.Values) url.Values {
+	redacted := make(url.Values, len(values))
+	for key, list := range values {
+		copied := make([]string, len(list))
+		for i, value := range list {
+			copied[i] = r.field(key, value)
+		}
+		redacted[key] = copied
+	}
+
+	return redacted
+}
+
+// URL returns rawURL with the secrets removed from its query. A URL that cannot be parsed
+// is returned without its query.
+func (r *Redactor) URL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		base, _, _ := strings.Cut(rawURL, "?")
+
+		return base
+	}
+
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery = encodeQuery(r.Query(u.Query()))
+	}
+
+	return u.String()
+}
+
+// Phone masks a customer phone number when phone numbers are masked, keeping its last
+// four characters.
+func (r *Redactor) Phone(phone string) string {
+	if r == nil || !r.maskPhoneNumbers || phone == "" {
+		return phone
+	}
+
+	const visible = 4
+	if len(phone) <= visible {
+		return strings.Repeat("*", len(phone))
+	}
+
+	return strings.Repeat("*", len(phone)-visible) + phone[len(phone)-visible:]
+}
+
+// Text masks the text of a message when message bodies are masked.
+func (r *Redactor) Text(text string) string {
+	if r == nil || !r.maskMessageBodies || text == "" {
+		return text
+	}
+
+	return Redacted
+}
+
+// Truncate cuts s to at most the maximum body size in bytes. It cuts on a rune boundary, so
+// a multi-byte character is never split.
+func (r *Redactor) Truncate(s string) string {
+	size := DefaultMaxBodySize
+	if r != nil {
+		size = r.maxBodySize
+	}
+
+	if size < 0 || len(s) <= size {
+		return s
+	}
+
+	for size > 0 && !utf8.RuneStart(s[size]) {
+		size--
+	}
+
+	return fmt.Sprintf("%s...(%d bytes truncated)", s[:size], len(s)-size)
+}
+
+// Body returns body, of the given content type, with the secrets removed, phone numbers
+// and message bodies masked as configured and truncated. JSON and form bodies are
+// redacted field by field, other bodies are only truncated.
+func (r *Redactor) Body(contentType string, body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(trimmed)); err == nil {
+			return r.Truncate(encodeQuery(r.Query(values)))
+		}
+	}
+
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		if redacted, ok := r.json(trimmed); ok {
+			return r.Truncate(redacted)
+		}
+	}
+
+	if strings.HasPrefix(contentType, "multipart/") {
+		return fmt.Sprintf("(%s body of %d bytes)", contentType, len(body))
+	}
+
+	return r.Truncate(string(trimmed))
+}
+
+// field redacts the value of a query parameter or form field. A batch field holds JSON.
+func (r *Redactor) field(key, value string) string {
+	if IsSecret(key) {
+		return Redacted
+	}
+
+	if redacted, ok := r.json([]byte(value)); ok {
+		return redacted
+	}
+
+	return value
+}
+
+func (r *Redactor) json(data []byte) (string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", false
+	}
+
+	switch value.(type) {
+	case map[string]any, []any:
+	default:
+		return "", false
+	}
+
+	encoded, err := json.Marshal(r.walk("", value))
+	if err != nil {
+		return "", false
+	}
+
+	return string(encoded), true
+}
+
+// walk redacts value, the value of the JSON key.
+func (r *Redactor) walk(key string, value any) any {
+	switch {
+	case IsSecret(key):
+		return Redacted
+	case bodyKeys[key] && r != nil && r.maskMessageBodies:
+		return Redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = r.walk(k, item)
+		}
+
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = r.walk(key, item)
+		}
+
+		return v
+	case string:
+		if phoneKeys[key] {
+			return r.Phone(v)
+		}
+
+		// the operations of a batch request hold their own encoded bodies
+		if key == "body" || key == "relative_url" {
+			return r.embedded(v)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
+
+// embedded redacts a form encoded body or a relative URL embedded in a JSON string.
+func (r *Redactor) embedded(s string) string {
+	if !strings.Contains(s, "=") {
+		return s
+	}
+
+	path, query, found := strings.Cut(s, "?")
+	if !found {
+		path, query = "", s
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil || !hasSecret(values) {
+		return s
+	}
+
+	redacted := encodeQuery(r.Query(values))
+	if found {
+		return path + "?" + redacted
+	}
+
+	return redacted
+}
+
+func hasSecret(values url.Values) bool {
+	for key := range values {
+		if IsSecret(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodeQuery encodes values like url.Values.Encode but leaves Redacted readable.
+func encodeQuery(values url.Values) string {
+	return strings.ReplaceAll(values.Encode(), url.QueryEscape(Redacted), Redacted)
+}
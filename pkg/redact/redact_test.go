@@ -0,0 +1,143 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package redact
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRedactorHeader(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+	}{
+		{name: "bearer token", key: "Authorization", value: "Bearer EAAG", want: "Bearer [REDACTED]"},
+		{name: "signature", key: "x-hub-signature-256", value: "sha256=abcd", want: Redacted},
+		{name: "content type", key: "Content-Type", value: "application/json", want: "application/json"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var redactor *Redactor
+			if got := redactor.Header(tt.key, tt.value); got != tt.want {
+				t.Errorf("Header() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorURL(t *testing.T) {
+	t.Parallel()
+	got := New().URL("https://graph.facebook.com/v16.0/1234?access_token=EAAG&appsecret_proof=abcd&fields=id")
+	if strings.Contains(got, "EAAG") || strings.Contains(got, "abcd") || !strings.Contains(got, "fields=id") {
+		t.Errorf("URL() = %q", got)
+	}
+}
+
+func TestRedactorBody(t *testing.T) {
+	t.Parallel()
+	message := `{"messaging_product":"whatsapp","to":"255712345678","type":"text",` +
+		`"text":{"body":"my pin is 1234"},"access_token":"EAAG"}`
+	tests := []struct {
+		name        string
+		options     []Option
+		contentType string
+		body        string
+		contains    []string
+		excludes    []string
+	}{
+		{
+			name:        "secrets are always redacted",
+			contentType: "application/json",
+			body:        message,
+			contains:    []string{`"access_token":"[REDACTED]"`, "255712345678", "my pin is 1234"},
+			excludes:    []string{"EAAG"},
+		},
+		{
+			name:        "phone numbers and bodies masked",
+			options:     []Option{MaskPhoneNumbers(), MaskMessageBodies()},
+			contentType: "application/json",
+			body:        message,
+			contains:    []string{`"to":"********5678"`, `"text":"[REDACTED]"`},
+			excludes:    []string{"EAAG", "255712345678", "my pin"},
+		},
+		{
+			name:        "form body",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "access_token=EAAG&batch=" + `[{"method":"GET","relative_url":"me?access_token=EAAG"}]`,
+			contains:    []string{"access_token=[REDACTED]", "batch="},
+			excludes:    []string{"EAAG"},
+		},
+		{
+			name:        "truncated",
+			options:     []Option{MaxBodySize(4)},
+			contentType: "text/plain",
+			body:        "0123456789",
+			contains:    []string{"0123...(6 bytes truncated)"},
+		},
+		{
+			name:        "truncated on a rune boundary",
+			options:     []Option{MaxBodySize(4)},
+			contentType: "text/plain",
+			body:        "abcÜber",
+			contains:    []string{"abc...(5 bytes truncated)"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := New(tt.options...).Body(tt.contentType, []byte(tt.body))
+			if !utf8.ValidString(got) {
+				t.Errorf("Body() = %q is not valid UTF-8", got)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("Body() = %s, want it to contain %s", got, s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(got, s) {
+					t.Errorf("Body() = %s, want it not to contain %s", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactorHeaders(t *testing.T) {
+	t.Parallel()
+	headers := http.Header{"Authorization": {"Bearer EAAG"}, "Accept": {"*/*"}}
+	got := New().Headers(headers)
+	if got.Get("Authorization") != "Bearer [REDACTED]" || got.Get("Accept") != "*/*" {
+		t.Errorf("Headers() = %v", got)
+	}
+
+	if headers.Get("Authorization") != "Bearer EAAG" {
+		t.Errorf("Headers() modified the original headers")
+	}
+}
@@ -21,9 +21,13 @@ package whatsapp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/piusalfred/whatsapp/pkg/codec"
 	whttp "github.com/piusalfred/whatsapp/pkg/http"
 )
 
@@ -51,10 +55,12 @@ type (
 		Code             string `json:"code"`
 		PrefilledMessage string `json:"prefilled_message"`
 		DeepLinkURL      string `json:"deep_link_url"`
+		QRImageURL       string `json:"qr_image_url,omitempty"`
 	}
 
 	ListResponse struct {
-		Data []*Information `json:"data,omitempty"`
+		Data   []*Information `json:"data,omitempty"`
+		Paging *Paging        `json:"paging,omitempty"`
 	}
 
 	SuccessResponse struct {
@@ -68,24 +74,25 @@ func (c *BaseClient) CreateQR(ctx context.Context, rtx *whttp.RequestContext,
 	queryParams := map[string]string{
 		"prefilled_message": req.PrefilledMessage,
 		"generate_qr_image": string(req.ImageFormat),
-		"access_token":      rtx.Bearer,
 	}
 	reqCtx := &whttp.RequestContext{
 		Name:          "create qr code",
 		BaseURL:       rtx.BaseURL,
 		ApiVersion:    rtx.ApiVersion,
 		PhoneNumberID: rtx.PhoneNumberID,
-		Endpoints:     []string{"message_qrdls"},
+		TokenSource:   rtx.TokenSource,
+		Route:         whttp.QRCodeRoute(rtx.PhoneNumberID, "").WithAuth(whttp.AuthQuery),
 	}
 	params := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodPost,
+		Bearer:  rtx.Bearer,
 		Query:   queryParams,
 	}
 
 	var response CreateResponse
 
-	err := c.base.Do(ctx, params, &response)
+	err := c.do(ctx, params, &response)
 	if err != nil {
 		return nil, fmt.Errorf("qr code create: %w", err)
 	}
@@ -99,17 +106,17 @@ func (c *BaseClient) ListQR(ctx context.Context, request *RequestContext) (*List
 		BaseURL:       request.BaseURL,
 		ApiVersion:    request.ApiVersion,
 		PhoneNumberID: request.PhoneID,
-		Endpoints:     []string{"message_qrdls"},
+		Route:         whttp.QRCodeRoute(request.PhoneID, "").WithAuth(whttp.AuthQuery),
 	}
 
 	req := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodGet,
-		Query:   map[string]string{"access_token": request.AccessToken},
+		Bearer:  request.AccessToken,
 	}
 
 	var response ListResponse
-	err := c.base.Do(ctx, req, &response)
+	err := c.do(ctx, req, &response)
 	if err != nil {
 		return nil, fmt.Errorf("qr code list: %w", err)
 	}
@@ -137,16 +144,17 @@ func (c *BaseClient) Get(ctx context.Context, request *whttp.RequestContext, qrC
 		BaseURL:       request.BaseURL,
 		ApiVersion:    request.ApiVersion,
 		PhoneNumberID: request.PhoneNumberID,
-		Endpoints:     []string{"message_qrdls", qrCodeID},
+		TokenSource:   request.TokenSource,
+		Route:         whttp.QRCodeRoute(request.PhoneNumberID, qrCodeID).WithAuth(whttp.AuthQuery),
 	}
 
 	req := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodGet,
-		Query:   map[string]string{"access_token": request.Bearer},
+		Bearer:  request.Bearer,
 	}
 
-	err := c.base.Do(ctx, req, &list)
+	err := c.do(ctx, req, &list)
 	if err != nil {
 		return nil, fmt.Errorf("qr code get: %w", err)
 	}
@@ -168,21 +176,22 @@ func (c *BaseClient) UpdateQR(ctx context.Context, rtx *whttp.RequestContext, qr
 		BaseURL:       rtx.BaseURL,
 		ApiVersion:    rtx.ApiVersion,
 		PhoneNumberID: rtx.PhoneNumberID,
-		Endpoints:     []string{"message_qrdls", qrCodeID},
+		TokenSource:   rtx.TokenSource,
+		Route:         whttp.QRCodeRoute(rtx.PhoneNumberID, qrCodeID).WithAuth(whttp.AuthQuery),
 	}
 
 	request := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodPost,
+		Bearer:  rtx.Bearer,
 		Query: map[string]string{
 			"prefilled_message": req.PrefilledMessage,
 			"generate_qr_image": string(req.ImageFormat),
-			"access_token":      rtx.Bearer,
 		},
 	}
 
 	var resp SuccessResponse
-	err := c.base.Do(ctx, request, &resp)
+	err := c.do(ctx, request, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("qr code update (%s): %w", qrCodeID, err)
 	}
@@ -197,19 +206,276 @@ func (c *BaseClient) DeleteQR(ctx context.Context, rtx *whttp.RequestContext, qr
 		BaseURL:       rtx.BaseURL,
 		ApiVersion:    rtx.ApiVersion,
 		PhoneNumberID: rtx.PhoneNumberID,
-		Endpoints:     []string{"message_qrdls", qrCodeID},
+		TokenSource:   rtx.TokenSource,
+		Route:         whttp.QRCodeRoute(rtx.PhoneNumberID, qrCodeID).WithAuth(whttp.AuthQuery),
 	}
 
 	req := &whttp.Request{
 		Context: reqCtx,
 		Method:  http.MethodDelete,
-		Query:   map[string]string{"access_token": rtx.Bearer},
+		Bearer:  rtx.Bearer,
 	}
 	var resp SuccessResponse
-	err := c.base.Do(ctx, req, &resp)
+	err := c.do(ctx, req, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("qr code delete: %w", err)
 	}
 
 	return &resp, nil
 }
+
+// QRCodeEndpoint is the endpoint, relative to the phone number, that manages the QR codes.
+const QRCodeEndpoint = whttp.QRCodeEdge
+
+// DefaultQRCodeFields are the fields requested when no fields are selected.
+//
+//nolint:gochecknoglobals
+var DefaultQRCodeFields = []string{"code", "prefilled_message", "deep_link_url"}
+
+// QRCodeListOptions selects the fields and the page of QR codes returned by ListQRCodes
+// and QRCodes.
+type QRCodeListOptions struct {
+	// Fields to return, DefaultQRCodeFields when empty.
+	Fields []string
+
+	// ImageFormat, when set, also returns a QRImageURL of the image in that format.
+	ImageFormat ImageFormat
+
+	// Limit is the maximum number of QR codes in a page, the API default when zero.
+	Limit int
+
+	// After and Before are the paging cursors returned with the previous page.
+	After  string
+	Before string
+}
+
+func (opts *QRCodeListOptions) query() map[string]string {
+	if opts == nil {
+		opts = &QRCodeListOptions{}
+	}
+
+	query := map[string]string{"fields": qrCodeFields(opts.Fields, opts.ImageFormat)}
+	if opts.Limit > 0 {
+		query["limit"] = strconv.Itoa(opts.Limit)
+	}
+
+	if opts.After != "" {
+		query["after"] = opts.After
+	}
+
+	if opts.Before != "" {
+		query["before"] = opts.Before
+	}
+
+	return query
+}
+
+func qrCodeFields(fields []string, format ImageFormat) string {
+	if len(fields) == 0 {
+		fields = DefaultQRCodeFields
+	}
+
+	if format != "" {
+		fields = append(fields[:len(fields):len(fields)], fmt.Sprintf("qr_image_url.format(%s)", format))
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// qrCodeRequest returns a request to the QR codes endpoint of the configured phone number,
+// authenticated with the configured access token.
+func (client *Client) qrCodeRequest(name, method string, query map[string]string, code string,
+) *whttp.Request {
+	config := client.config()
+
+	return &whttp.Request{
+		Context: &whttp.RequestContext{
+			TokenSource:       client.tokens,
+			Name:              name,
+			BaseURL:           config.BaseURL,
+			ApiVersion:        config.Version,
+			PhoneNumberID:     config.PhoneNumberID,
+			BusinessAccountID: config.BusinessAccountID,
+			Route:             whttp.QRCodeRoute(config.PhoneNumberID, code),
+		},
+		Method: method,
+		Bearer: config.AccessToken,
+		Query:  query,
+	}
+}
+
+// CreateQRCode creates a QR code that opens a chat with the configured phone number and the
+// prefilled message. When req.ImageFormat is set the response contains a QRImageURL.
+func (client *Client) CreateQRCode(ctx context.Context, req *CreateRequest,
+	opts ...CallOption,
+) (*CreateResponse, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	query := map[string]string{"prefilled_message": req.PrefilledMessage}
+	if req.ImageFormat != "" {
+		query["generate_qr_image"] = string(req.ImageFormat)
+	}
+
+	var response CreateResponse
+	if err := client.bc.do(ctx, client.qrCodeRequest("create qr code", http.MethodPost, query, ""),
+		&response); err != nil {
+		return nil, fmt.Errorf("create qr code: %w", err)
+	}
+
+	return &response, nil
+}
+
+// QRCode returns the QR code with the given code. Only the given fields are returned,
+// DefaultQRCodeFields when fields is empty.
+func (client *Client) QRCode(ctx context.Context, code string, fields []string,
+	opts ...CallOption,
+) (*Information, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	query := map[string]string{"fields": qrCodeFields(fields, "")}
+
+	var raw json.RawMessage
+	if err := client.bc.do(ctx, client.qrCodeRequest("get qr code", http.MethodGet, query, code),
+		&raw); err != nil {
+		return nil, fmt.Errorf("get qr code (%s): %w", code, err)
+	}
+
+	// the API wraps the QR code in a list, accept a bare object too
+	c := codec.OrDefault(client.bc.codec)
+	var list ListResponse
+	if err := c.Unmarshal(raw, &list); err == nil && len(list.Data) > 0 {
+		return list.Data[0], nil
+	}
+
+	var info Information
+	if err := c.Unmarshal(raw, &info); err != nil || info.Code == "" {
+		return nil, fmt.Errorf("get qr code (%s): %w", code, ErrNoDataFound)
+	}
+
+	return &info, nil
+}
+
+// ListQRCodes returns a single page of the QR codes of the configured phone number. Use the
+// cursors in the returned Paging to fetch the next page, or QRCodes to go through all of them.
+func (client *Client) ListQRCodes(ctx context.Context, opts *QRCodeListOptions,
+	callOpts ...CallOption,
+) (*ListResponse, error) {
+	ctx = client.withCallOptions(ctx, callOpts)
+	var response ListResponse
+	if err := client.bc.do(ctx, client.qrCodeRequest("list qr codes", http.MethodGet, opts.query(), ""),
+		&response); err != nil {
+		return nil, fmt.Errorf("list qr codes: %w", err)
+	}
+
+	return &response, nil
+}
+
+// QRCodes returns an iterator over all the QR codes of the configured phone number, fetching
+// the pages as they are needed. The call options apply to every page.
+//
+//	codes := client.QRCodes(&whatsapp.QRCodeListOptions{Limit: 100})
+//	for codes.Next(ctx) {
+//		fmt.Println(codes.QRCode().DeepLinkURL)
+//	}
+//	if err := codes.Err(); err != nil {
+//		return err
+//	}
+func (client *Client) QRCodes(opts *QRCodeListOptions, callOpts ...CallOption) *QRCodeIterator {
+	options := QRCodeListOptions{}
+	if opts != nil {
+		options = *opts
+	}
+
+	return &QRCodeIterator{client: client, options: options, callOpts: callOpts}
+}
+
+// UpdateQRCode changes the prefilled message of the QR code with the given code.
+func (client *Client) UpdateQRCode(ctx context.Context, code string, req *CreateRequest,
+	opts ...CallOption,
+) (*CreateResponse, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	query := map[string]string{"prefilled_message": req.PrefilledMessage}
+	if req.ImageFormat != "" {
+		query["generate_qr_image"] = string(req.ImageFormat)
+	}
+
+	var response CreateResponse
+	if err := client.bc.do(ctx, client.qrCodeRequest("update qr code", http.MethodPost, query, code),
+		&response); err != nil {
+		return nil, fmt.Errorf("update qr code (%s): %w", code, err)
+	}
+
+	return &response, nil
+}
+
+// DeleteQRCode deletes the QR code with the given code.
+func (client *Client) DeleteQRCode(ctx context.Context, code string, opts ...CallOption) (*SuccessResponse, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	var response SuccessResponse
+	if err := client.bc.do(ctx, client.qrCodeRequest("delete qr code", http.MethodDelete, nil, code),
+		&response); err != nil {
+		return nil, fmt.Errorf("delete qr code (%s): %w", code, err)
+	}
+
+	return &response, nil
+}
+
+// QRCodeIterator goes through the QR codes of a phone number page by page. It is not safe
+// for concurrent use.
+type QRCodeIterator struct {
+	client   *Client
+	options  QRCodeListOptions
+	callOpts []CallOption
+	page     []*Information
+	current  *Information
+	done     bool
+	err      error
+}
+
+// Next advances to the next QR code, fetching the next page when the current one is
+// exhausted. It returns false when there are no more QR codes or an error occurred.
+func (it *QRCodeIterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			it.current = nil
+
+			return false
+		}
+
+		it.fetch(ctx)
+	}
+
+	it.current, it.page = it.page[0], it.page[1:]
+
+	return true
+}
+
+func (it *QRCodeIterator) fetch(ctx context.Context) {
+	response, err := it.client.ListQRCodes(ctx, &it.options, it.callOpts...)
+	if err != nil {
+		it.err = err
+
+		return
+	}
+
+	it.page = response.Data
+
+	// the API omits the next link on the last page
+	if response.Paging == nil || response.Paging.Next == "" || response.Paging.Cursors == nil ||
+		response.Paging.Cursors.After == "" || len(response.Data) == 0 {
+		it.done = true
+
+		return
+	}
+
+	it.options.After = response.Paging.Cursors.After
+	it.options.Before = ""
+}
+
+// QRCode returns the current QR code.
+func (it *QRCodeIterator) QRCode() *Information {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *QRCodeIterator) Err() error {
+	return it.err
+}
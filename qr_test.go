@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientQRCodes(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.URL.Query().Has("access_token") {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v16.0/1234/message_qrdls":
+			if r.URL.Query().Get("fields") != "code,prefilled_message,deep_link_url" {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			// two pages of two QR codes each
+			if r.URL.Query().Get("after") == "" {
+				_, _ = fmt.Fprint(w, `{"data":[{"code":"A"},{"code":"B"}],`+
+					`"paging":{"cursors":{"after":"c1"},"next":"https://graph.facebook.com/next"}}`)
+
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"data":[{"code":"C"},{"code":"D"}],"paging":{"cursors":{"after":"c2"}}}`)
+		case "/v16.0/1234/message_qrdls/A":
+			_, _ = fmt.Fprint(w, `{"data":[{"code":"A","deep_link_url":"https://wa.me/message/A"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL, AccessToken: "token", PhoneNumberID: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var codes []string
+	iterator := client.QRCodes(&QRCodeListOptions{Limit: 2})
+	for iterator.Next(context.TODO()) {
+		codes = append(codes, iterator.QRCode().Code)
+	}
+
+	if err = iterator.Err(); err != nil {
+		t.Fatalf("iteration error = %v", err)
+	}
+
+	if fmt.Sprint(codes) != "[A B C D]" {
+		t.Errorf("codes = %v, want [A B C D]", codes)
+	}
+
+	info, err := client.QRCode(context.TODO(), "A", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.DeepLinkURL != "https://wa.me/message/A" {
+		t.Errorf("unexpected deep link %q", info.DeepLinkURL)
+	}
+
+	if _, err = client.QRCode(context.TODO(), "missing", nil); err == nil {
+		t.Errorf("expected an error for a missing QR code")
+	}
+}
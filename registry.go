@@ -0,0 +1,300 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/metrics"
+	"github.com/piusalfred/whatsapp/pkg/redact"
+	"github.com/piusalfred/whatsapp/webhooks"
+)
+
+var ErrTenantNotFound = errors.New("tenant not found")
+
+type (
+	// Tenant is a business, brand or WhatsApp Business Account served by a Registry. Its
+	// Config holds the phone number and credentials, and TokenSource, when set, supplies the
+	// access tokens instead of Config.AccessToken.
+	Tenant struct {
+		Name        string
+		Config      *Config
+		TokenSource whttp.TokenSource
+	}
+
+	// TenantStore looks tenants up by name or by phone number ID.
+	TenantStore interface {
+		Tenant(ctx context.Context, key string) (*Tenant, error)
+	}
+
+	// TenantStoreFunc is a function that implements the TenantStore interface.
+	TenantStoreFunc func(ctx context.Context, key string) (*Tenant, error)
+
+	// MemoryTenantStore is a TenantStore that keeps the tenants in memory, indexed by name and
+	// by phone number ID. It is safe for concurrent use.
+	MemoryTenantStore struct {
+		mu      sync.RWMutex
+		tenants map[string]*Tenant
+	}
+
+	// Registry hands out a Client per tenant. All the clients share one BaseClient, and so one
+	// http client, set of hooks and set of middleware, while each uses the credentials of its
+	// tenant. Clients are created on first use and reused afterwards.
+	//
+	//	store := whatsapp.NewMemoryTenantStore(
+	//		&whatsapp.Tenant{Name: "brand-a", Config: configA},
+	//		&whatsapp.Tenant{Name: "brand-b", Config: configB},
+	//	)
+	//	registry := whatsapp.NewRegistry(store)
+	//	client, err := registry.Client(ctx, "brand-a")
+	Registry struct {
+		base       *BaseClient
+		store      TenantStore
+		options    []ClientOption
+		secret     string
+		metrics    *metrics.Registry
+		logger     *slog.Logger
+		logOptions []redact.Option
+		mu         sync.Mutex
+		clients    map[string]*Client
+	}
+
+	// RegistryOption configures a Registry.
+	RegistryOption func(*Registry)
+)
+
+// Tenant implements the TenantStore interface.
+func (fn TenantStoreFunc) Tenant(ctx context.Context, key string) (*Tenant, error) {
+	return fn(ctx, key)
+}
+
+// NewMemoryTenantStore returns a MemoryTenantStore holding the tenants.
+func NewMemoryTenantStore(tenants ...*Tenant) *MemoryTenantStore {
+	store := &MemoryTenantStore{tenants: make(map[string]*Tenant)}
+	for _, tenant := range tenants {
+		store.Put(tenant)
+	}
+
+	return store
+}
+
+// Put adds or replaces a tenant.
+func (store *MemoryTenantStore) Put(tenant *Tenant) {
+	if tenant == nil {
+		return
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if tenant.Name != "" {
+		store.tenants[tenant.Name] = tenant
+	}
+
+	if tenant.Config != nil && tenant.Config.PhoneNumberID != "" {
+		store.tenants[tenant.Config.PhoneNumberID] = tenant
+	}
+}
+
+// Tenant implements the TenantStore interface.
+func (store *MemoryTenantStore) Tenant(_ context.Context, key string) (*Tenant, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	tenant, ok := store.tenants[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, key)
+	}
+
+	return tenant, nil
+}
+
+// WithRegistryBaseClient sets the BaseClient shared by the clients of the registry.
+func WithRegistryBaseClient(base *BaseClient) RegistryOption {
+	return func(registry *Registry) {
+		if base != nil {
+			registry.base = base
+		}
+	}
+}
+
+// WithRegistryClientOptions sets options applied to every client created by the registry.
+// WithMetrics and WithLogger have no effect on these clients, which share the BaseClient of
+// the registry, use WithRegistryMetrics and WithRegistryLogger instead.
+func WithRegistryClientOptions(options ...ClientOption) RegistryOption {
+	return func(registry *Registry) {
+		registry.options = append(registry.options, options...)
+	}
+}
+
+// WithRegistryAppSecret sets the app secret used to sign the requests of the tenants whose
+// Config has no AppSecret, for tenants that are served by the same app. A tenant with its own
+// AppSecret is always signed with it.
+func WithRegistryAppSecret(secret string) RegistryOption {
+	return func(registry *Registry) {
+		registry.secret = secret
+	}
+}
+
+// WithRegistryMetrics records the calls of all the tenants in metricsRegistry, see
+// WithBaseClientMetrics. The metrics are added to the shared BaseClient, also when it is set
+// with WithRegistryBaseClient, so that BaseClient should not record them itself.
+func WithRegistryMetrics(metricsRegistry *metrics.Registry) RegistryOption {
+	return func(registry *Registry) {
+		registry.metrics = metricsRegistry
+	}
+}
+
+// WithRegistryLogger makes the clients of all the tenants log their calls to logger, see
+// WithBaseClientLogger. Like WithRegistryMetrics, it configures the shared BaseClient.
+func WithRegistryLogger(logger *slog.Logger, options ...redact.Option) RegistryOption {
+	return func(registry *Registry) {
+		registry.logger = logger
+		registry.logOptions = options
+	}
+}
+
+// NewRegistry returns a Registry that resolves tenants from store.
+func NewRegistry(store TenantStore, options ...RegistryOption) *Registry {
+	registry := &Registry{
+		base:    NewBaseClient(),
+		store:   store,
+		clients: make(map[string]*Client),
+	}
+
+	for _, option := range options {
+		if option == nil {
+			continue
+		}
+		option(registry)
+	}
+
+	registry.base.base.AppendRequestHooks(whttp.AppSecretProofHookFunc(registry.appSecret))
+
+	if registry.metrics != nil {
+		registry.base.setMetrics(registry.metrics)
+	}
+
+	if registry.logger != nil {
+		registry.base.setLogger(registry.logger, registry.logOptions...)
+	}
+
+	return registry
+}
+
+// appSecret returns the app secret of the tenant making the call, or the one set with
+// WithRegistryAppSecret when the tenant has none.
+func (registry *Registry) appSecret(ctx context.Context) string {
+	if client, ok := clientFromContext(ctx); ok {
+		if secret := client.config().AppSecret; secret != "" {
+			return secret
+		}
+	}
+
+	return registry.secret
+}
+
+// BaseClient returns the BaseClient shared by the clients of the registry.
+func (registry *Registry) BaseClient() *BaseClient {
+	return registry.base
+}
+
+// Client returns the client of the tenant with the given name or phone number ID. The store
+// is not called with the registry locked, so a slow store does not hold up the other tenants.
+func (registry *Registry) Client(ctx context.Context, key string) (*Client, error) {
+	registry.mu.Lock()
+	client, ok := registry.clients[key]
+	registry.mu.Unlock()
+
+	if ok {
+		return client, nil
+	}
+
+	tenant, err := registry.store.Tenant(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+
+	if tenant == nil || tenant.Config == nil {
+		return nil, fmt.Errorf("registry: %w: %s has no config", ErrTenantNotFound, key)
+	}
+
+	config := *tenant.Config
+	options := append([]ClientOption{WithBaseClient(registry.base)}, registry.options...)
+	if tenant.TokenSource != nil {
+		options = append(options, WithTokenSource(tenant.TokenSource))
+	}
+
+	created, err := NewClientWithConfig(&config, options...)
+	if err != nil {
+		return nil, fmt.Errorf("registry: tenant %s: %w", key, err)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	// another call may have created the client while the store was called, and the same
+	// tenant may be asked for by name and by phone number ID
+	for _, other := range []string{key, tenant.Name, tenant.Config.PhoneNumberID} {
+		if client, ok := registry.clients[other]; ok && other != "" {
+			registry.clients[key] = client
+
+			return client, nil
+		}
+	}
+
+	registry.clients[key] = created
+
+	return created, nil
+}
+
+// NotificationClient returns the client of the tenant whose phone number received the
+// webhook notification.
+func (registry *Registry) NotificationClient(ctx context.Context, nctx *webhooks.NotificationContext,
+) (*Client, error) {
+	if nctx == nil || nctx.Metadata == nil || nctx.Metadata.PhoneNumberID == "" {
+		return nil, fmt.Errorf("registry: %w: notification has no phone number id", ErrTenantNotFound)
+	}
+
+	return registry.Client(ctx, nctx.Metadata.PhoneNumberID)
+}
+
+// Forget drops the clients of the tenant with the given name or phone number ID, so that the
+// next call to Client reads the tenant from the store again.
+func (registry *Registry) Forget(key string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	client, ok := registry.clients[key]
+	if !ok {
+		return
+	}
+
+	for k, c := range registry.clients {
+		if c == client {
+			delete(registry.clients, k)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/metrics"
+	"github.com/piusalfred/whatsapp/webhooks"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = r.Header.Get("Authorization")
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	store := NewMemoryTenantStore(
+		&Tenant{Name: "brand-a", Config: &Config{BaseURL: server.URL, PhoneNumberID: "111", AccessToken: "token-a"}},
+		&Tenant{
+			Name:        "brand-b",
+			Config:      &Config{BaseURL: server.URL, PhoneNumberID: "222"},
+			TokenSource: whttp.StaticTokenSource("token-b"),
+		},
+	)
+	registry := NewRegistry(store)
+
+	clientA, err := registry.Client(context.TODO(), "brand-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nctx := &webhooks.NotificationContext{Metadata: &webhooks.Metadata{PhoneNumberID: "111"}}
+	routed, err := registry.NotificationClient(context.TODO(), nctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if routed != clientA || clientA.bc != registry.BaseClient() {
+		t.Errorf("expected the notification to be routed to the shared client of brand-a")
+	}
+
+	clientB, err := registry.Client(context.TODO(), "222")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, client := range []*Client{clientA, clientB} {
+		if _, err = client.DeleteQRCode(context.TODO(), "code"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if seen["/v16.0/111/message_qrdls/code"] != "Bearer token-a" ||
+		seen["/v16.0/222/message_qrdls/code"] != "Bearer token-b" {
+		t.Errorf("unexpected credentials per tenant: %v", seen)
+	}
+
+	if _, err = registry.Client(context.TODO(), "unknown"); !errors.Is(err, ErrTenantNotFound) {
+		t.Errorf("expected ErrTenantNotFound, got %v", err)
+	}
+}
+
+func TestRegistryAppSecret(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	proofs := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proofs[r.URL.Path] = r.URL.Query().Get(whttp.AppSecretProofParam)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	store := NewMemoryTenantStore(
+		&Tenant{Name: "a", Config: &Config{
+			BaseURL: server.URL, PhoneNumberID: "111", AccessToken: "token-a", AppSecret: "secret-a",
+		}},
+		&Tenant{Name: "b", Config: &Config{
+			BaseURL: server.URL, PhoneNumberID: "222", AccessToken: "token-b", AppSecret: "secret-b",
+		}},
+		&Tenant{Name: "c", Config: &Config{BaseURL: server.URL, PhoneNumberID: "333", AccessToken: "token-c"}},
+	)
+	registry := NewRegistry(store, WithRegistryAppSecret("shared"))
+
+	for _, name := range []string{"a", "b", "c"} {
+		client, err := registry.Client(context.TODO(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = client.DeleteQRCode(context.TODO(), "code"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		"/v16.0/111/message_qrdls/code": whttp.AppSecretProof("secret-a", "token-a"),
+		"/v16.0/222/message_qrdls/code": whttp.AppSecretProof("secret-b", "token-b"),
+		"/v16.0/333/message_qrdls/code": whttp.AppSecretProof("shared", "token-c"),
+	}
+	for path, proof := range want {
+		if proofs[path] != proof {
+			t.Errorf("appsecret_proof of %s = %q, want %q", path, proofs[path], proof)
+		}
+	}
+}
+
+func TestRegistryMetricsAndLogger(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	store := NewMemoryTenantStore(
+		&Tenant{Name: "a", Config: &Config{BaseURL: server.URL, PhoneNumberID: "111", AccessToken: "token-a"}},
+		&Tenant{Name: "b", Config: &Config{BaseURL: server.URL, PhoneNumberID: "222", AccessToken: "token-b"}},
+	)
+
+	var buf bytes.Buffer
+	metricsRegistry := metrics.NewRegistry()
+	registry := NewRegistry(store,
+		WithRegistryMetrics(metricsRegistry),
+		WithRegistryLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+	)
+
+	for _, name := range []string{"a", "b"} {
+		client, err := registry.Client(context.TODO(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = client.DeleteQRCode(context.TODO(), "code"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	operations := metricsRegistry.Counter(MetricOperations, "", "operation", "status", "error_code")
+	if got := operations.Value("delete qr code", "ok", ""); got != 2 {
+		t.Errorf("delete qr code operations = %v, want 2", got)
+	}
+
+	if got := strings.Count(buf.String(), `msg="whatsapp operation"`); got != 2 {
+		t.Errorf("logged %d operations, want 2: %s", got, buf.String())
+	}
+
+	if strings.Contains(buf.String(), "token-a") {
+		t.Errorf("access token logged: %s", buf.String())
+	}
+}
+
+func TestRegistryStoreNotLocked(t *testing.T) {
+	t.Parallel()
+	var registry *Registry
+	store := TenantStoreFunc(func(ctx context.Context, key string) (*Tenant, error) {
+		if key == "outer" {
+			// a store that calls back into the registry must not deadlock
+			if _, err := registry.Client(ctx, "inner"); err != nil {
+				return nil, err
+			}
+		}
+
+		return &Tenant{Name: key, Config: &Config{PhoneNumberID: key, AccessToken: "token"}}, nil
+	})
+	registry = NewRegistry(store)
+
+	outer, err := registry.Client(context.TODO(), "outer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := registry.Client(context.TODO(), "outer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if outer != again {
+		t.Errorf("expected the client of a tenant to be reused")
+	}
+}
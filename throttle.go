@@ -0,0 +1,242 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+const (
+	// DefaultThrottleSlowDownAt is the usage, in percent, from which calls are delayed.
+	DefaultThrottleSlowDownAt = 75
+
+	// DefaultThrottlePauseAt is the usage, in percent, from which calls wait MaxDelay.
+	DefaultThrottlePauseAt = 95
+
+	// DefaultThrottleMaxDelay is the longest delay added because of high usage.
+	DefaultThrottleMaxDelay = 10 * time.Second
+
+	// DefaultThrottleUsageMaxAge is how long a reported usage is taken into account.
+	DefaultThrottleUsageMaxAge = 5 * time.Minute
+)
+
+type (
+	// ThrottleOption configures the middleware returned by ThrottleMiddleware.
+	ThrottleOption func(throttle *throttle)
+
+	// ThrottleFunc is called before a call is delayed, with the delay and the rate limit
+	// that caused it.
+	ThrottleFunc func(ctx context.Context, delay time.Duration, key whttp.UsageKey, usage whttp.Usage)
+
+	throttle struct {
+		tracker    *whttp.UsageTracker
+		slowDownAt float64
+		pauseAt    float64
+		maxDelay   time.Duration
+		maxAge     time.Duration
+		onThrottle ThrottleFunc
+		now        func() time.Time
+		sleep      func(ctx context.Context, d time.Duration) error
+	}
+)
+
+// WithThrottleThresholds sets the usage, in percent, from which calls are slowed down and
+// from which they are paused.
+func WithThrottleThresholds(slowDownAt, pauseAt float64) ThrottleOption {
+	return func(throttle *throttle) {
+		throttle.slowDownAt = slowDownAt
+		throttle.pauseAt = pauseAt
+	}
+}
+
+// WithThrottleMaxDelay sets the delay of calls made when the usage is above the pause
+// threshold. Calls between the thresholds are delayed proportionally.
+func WithThrottleMaxDelay(delay time.Duration) ThrottleOption {
+	return func(throttle *throttle) {
+		throttle.maxDelay = delay
+	}
+}
+
+// WithThrottleUsageMaxAge sets how long a reported usage is taken into account. The usage
+// is only reported in responses, so without it a single high value would slow every call.
+func WithThrottleUsageMaxAge(age time.Duration) ThrottleOption {
+	return func(throttle *throttle) {
+		throttle.maxAge = age
+	}
+}
+
+// WithThrottleCallback sets a function that is called every time a call is delayed.
+func WithThrottleCallback(fn ThrottleFunc) ThrottleOption {
+	return func(throttle *throttle) {
+		throttle.onThrottle = fn
+	}
+}
+
+// ThrottleMiddleware returns an OperationMiddleware that delays calls as the usage
+// recorded by tracker approaches the rate limits that apply to them, and waits until the
+// estimated_time_to_regain_access has passed when a limit has been reached. A delayed
+// call returns early with the context error if ctx is done first.
+//
+//	tracker := whttp.NewUsageTracker()
+//	base := whatsapp.NewBaseClient(
+//		whatsapp.WithBaseHTTPClient(whttp.NewClient(whttp.WithResponseHeaderHooks(tracker.ResponseHook()))),
+//		whatsapp.WithOperationMiddleware(whatsapp.ThrottleMiddleware(tracker)),
+//	)
+func ThrottleMiddleware(tracker *whttp.UsageTracker, opts ...ThrottleOption) OperationMiddleware {
+	throttle := &throttle{
+		tracker:    tracker,
+		slowDownAt: DefaultThrottleSlowDownAt,
+		pauseAt:    DefaultThrottlePauseAt,
+		maxDelay:   DefaultThrottleMaxDelay,
+		maxAge:     DefaultThrottleUsageMaxAge,
+		now:        time.Now,
+		sleep:      sleep,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(throttle)
+		}
+	}
+
+	return func(next OperationHandler) OperationHandler {
+		return OperationHandlerFunc(func(ctx context.Context, operation *Operation) error {
+			if err := throttle.wait(ctx, operation); err != nil {
+				return fmt.Errorf("throttle %s: %w", operation.Name, err)
+			}
+
+			return next.Handle(ctx, operation)
+		})
+	}
+}
+
+// delay returns how long the operation should wait and the rate limit that causes it. The
+// app rate limit applies to every call, a business use case rate limit only to the calls
+// made on its business object, the phone number or the WhatsApp Business Account.
+//
+//nolint:cyclop
+func (throttle *throttle) delay(operation *Operation) (time.Duration, whttp.UsageKey, whttp.Usage) {
+	now := throttle.now()
+	ids := operationIDs(operation)
+
+	var (
+		longest    time.Duration
+		longestKey whttp.UsageKey
+		longestUse whttp.Usage
+		highestKey whttp.UsageKey
+		highest    whttp.Usage
+		found      bool
+	)
+	for key, usage := range throttle.tracker.Snapshot() {
+		if !usageApplies(key, ids) {
+			continue
+		}
+
+		if at := usage.RegainAccessAt(); at.After(now) && at.Sub(now) > longest {
+			longest, longestKey, longestUse = at.Sub(now), key, usage
+		}
+
+		if throttle.maxAge > 0 && now.Sub(usage.UpdatedAt) > throttle.maxAge {
+			continue
+		}
+
+		if !found || usage.Max() > highest.Max() {
+			highestKey, highest, found = key, usage, true
+		}
+	}
+
+	if longest > 0 {
+		return longest, longestKey, longestUse
+	}
+
+	if !found || highest.Max() < throttle.slowDownAt {
+		return 0, highestKey, highest
+	}
+
+	if highest.Max() >= throttle.pauseAt || throttle.pauseAt <= throttle.slowDownAt {
+		return throttle.maxDelay, highestKey, highest
+	}
+
+	ratio := (highest.Max() - throttle.slowDownAt) / (throttle.pauseAt - throttle.slowDownAt)
+
+	return time.Duration(ratio * float64(throttle.maxDelay)), highestKey, highest
+}
+
+// usageApplies reports whether the rate limit of key applies to a call made on the business
+// objects with the given ids.
+func usageApplies(key whttp.UsageKey, ids map[string]bool) bool {
+	switch key.Source {
+	case whttp.UsageSourceApp:
+		return true
+	case whttp.UsageSourceBusinessUseCase:
+		return ids[key.BusinessID]
+	default:
+		return false
+	}
+}
+
+// operationIDs returns the ids of the business objects the operation is made on.
+func operationIDs(operation *Operation) map[string]bool {
+	ids := map[string]bool{}
+	if operation == nil || operation.Request == nil || operation.Request.Context == nil {
+		return ids
+	}
+
+	rctx := operation.Request.Context
+	for _, id := range []string{rctx.PhoneNumberID, rctx.BusinessAccountID} {
+		if id != "" {
+			ids[id] = true
+		}
+	}
+
+	if rctx.Route != nil && rctx.Route.ID != "" {
+		ids[rctx.Route.ID] = true
+	}
+
+	return ids
+}
+
+func (throttle *throttle) wait(ctx context.Context, operation *Operation) error {
+	delay, key, usage := throttle.delay(operation)
+	if delay <= 0 {
+		return nil
+	}
+
+	if throttle.onThrottle != nil {
+		throttle.onThrottle(ctx, delay, key, usage)
+	}
+
+	return throttle.sleep(ctx, delay)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	case <-timer.C:
+		return nil
+	}
+}
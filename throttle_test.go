@@ -0,0 +1,156 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+func TestThrottleMiddleware(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   time.Duration
+	}{
+		{name: "no usage", want: 0},
+		{name: "low usage", header: whttp.AppUsageHeader, value: `{"call_count":50}`, want: 0},
+		{name: "slowing down", header: whttp.AppUsageHeader, value: `{"call_count":85}`, want: 5 * time.Second},
+		{name: "paused", header: whttp.AppUsageHeader, value: `{"total_time":97}`, want: 10 * time.Second},
+		{
+			name:   "throttled",
+			header: whttp.BusinessUseCaseUsageHeader,
+			value:  `{"1":[{"type":"whatsapp_business_messaging","call_count":100,"estimated_time_to_regain_access":2}]}`,
+			want:   2 * time.Minute,
+		},
+		{
+			name:   "other business throttled",
+			header: whttp.BusinessUseCaseUsageHeader,
+			value:  `{"2":[{"type":"whatsapp_business_messaging","call_count":100,"estimated_time_to_regain_access":2}]}`,
+			want:   0,
+		},
+		{
+			name:   "other business slowing down",
+			header: whttp.BusinessUseCaseUsageHeader,
+			value:  `{"2":[{"type":"whatsapp_business_messaging","call_count":90}]}`,
+			want:   0,
+		},
+		{
+			name:   "phone number slowing down",
+			header: whttp.BusinessUseCaseUsageHeader,
+			value:  `{"1234":[{"type":"whatsapp_business_messaging","call_count":85}]}`,
+			want:   5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.header != "" {
+					w.Header().Set(tt.header, tt.value)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var got time.Duration
+			tracker := whttp.NewUsageTracker()
+			base := NewBaseClient(
+				WithBaseHTTPClient(whttp.NewClient(whttp.WithResponseHeaderHooks(tracker.ResponseHook()))),
+				WithOperationMiddleware(ThrottleMiddleware(tracker,
+					WithThrottleCallback(func(_ context.Context, delay time.Duration, _ whttp.UsageKey, _ whttp.Usage) {
+						got = delay
+						cancel()
+					}))),
+			)
+
+			client, err := NewClientWithConfig(&Config{
+				BaseURL:           server.URL,
+				AccessToken:       "token",
+				PhoneNumberID:     "1234",
+				BusinessAccountID: "1",
+			}, WithBaseClient(base))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the first call records the usage, the second one is throttled by it
+			message := &TextMessage{Message: "hello"}
+			if _, err = client.SendText(ctx, "255700000000", message); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.SendText(ctx, "255700000000", message)
+			if (tt.want > 0) != errors.Is(err, context.Canceled) {
+				t.Errorf("SendText() error = %v, want a throttled call: %v", err, tt.want > 0)
+			}
+
+			// the regain time is measured from when the usage was recorded
+			if got > tt.want || got < tt.want-time.Second {
+				t.Errorf("delay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThrottleMiddlewareWaits(t *testing.T) {
+	t.Parallel()
+	tracker := whttp.NewUsageTracker()
+	header := http.Header{}
+	header.Set(whttp.AppUsageHeader, `{"call_count":99}`)
+	_ = tracker.Observe(header)
+
+	var throttled time.Duration
+	middleware := ThrottleMiddleware(tracker, WithThrottleMaxDelay(time.Hour),
+		WithThrottleCallback(func(_ context.Context, delay time.Duration, key whttp.UsageKey, _ whttp.Usage) {
+			if key.Source == whttp.UsageSourceApp {
+				throttled = delay
+			}
+		}))
+
+	called := false
+	handler := middleware(OperationHandlerFunc(func(context.Context, *Operation) error {
+		called = true
+
+		return nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := handler.Handle(ctx, &Operation{Name: "send message"})
+	if !errors.Is(err, context.DeadlineExceeded) || called || throttled != time.Hour {
+		t.Errorf("Handle() = %v, called = %v, delay = %v", err, called, throttled)
+	}
+}
@@ -0,0 +1,264 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+// configTokenSource returns the access token of the current configuration of a Client, so
+// that tokens rotated with UpdateConfig are picked up.
+type configTokenSource struct {
+	client *Client
+}
+
+func (source configTokenSource) Token(_ context.Context) (*whttp.Token, error) {
+	return &whttp.Token{AccessToken: source.client.config().AccessToken}, nil
+}
+
+type (
+	// ExchangeTokenRequest holds what is needed to exchange a short-lived user access token
+	// for a long-lived one using the oauth/access_token endpoint.
+	ExchangeTokenRequest struct {
+		BaseURL    string
+		ApiVersion string //nolint: revive,stylecheck
+		AppID      string
+		AppSecret  string
+		Token      string
+	}
+
+	// ExchangeTokenResponse is the response of the oauth/access_token endpoint.
+	ExchangeTokenResponse struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	// ExchangeTokenSource is a TokenSource that exchanges a short-lived token for a long-lived
+	// one, and later exchanges the long-lived token it holds for a fresh one. Wrap it with
+	// whttp.NewCachingTokenSource so that the exchange happens only ahead of expiry:
+	//
+	//	source := whttp.NewCachingTokenSource(whatsapp.NewExchangeTokenSource(base, request), 24*time.Hour)
+	//	client, err := whatsapp.NewClientWithConfig(config, whatsapp.WithTokenSource(source))
+	ExchangeTokenSource struct {
+		client  *BaseClient
+		request ExchangeTokenRequest
+		mu      sync.Mutex
+		now     func() time.Time
+	}
+)
+
+// ExchangeToken exchanges req.Token for a long-lived access token.
+//
+//	curl -i -X GET "https://graph.facebook.com/{graph-api-version}/oauth/access_token?
+//	  grant_type=fb_exchange_token&client_id={app-id}&client_secret={app-secret}&
+//	  fb_exchange_token={your-access-token}"
+func (c *BaseClient) ExchangeToken(ctx context.Context, req *ExchangeTokenRequest) (*ExchangeTokenResponse, error) {
+	params := &whttp.Request{
+		Context: &whttp.RequestContext{
+			Name:       "exchange token",
+			BaseURL:    req.BaseURL,
+			ApiVersion: req.ApiVersion,
+			Route:      whttp.RootRoute("oauth", "access_token"),
+		},
+		Method: http.MethodGet,
+		Query: map[string]string{
+			"grant_type":        "fb_exchange_token",
+			"client_id":         req.AppID,
+			"client_secret":     req.AppSecret,
+			"fb_exchange_token": req.Token,
+		},
+	}
+
+	var response ExchangeTokenResponse
+	if err := c.do(ctx, params, &response); err != nil {
+		return nil, fmt.Errorf("exchange token: %w", err)
+	}
+
+	return &response, nil
+}
+
+// NewExchangeTokenSource returns an ExchangeTokenSource starting from request.Token. Empty
+// BaseURL and ApiVersion default to the ones of NewClientWithConfig.
+func NewExchangeTokenSource(client *BaseClient, request *ExchangeTokenRequest) *ExchangeTokenSource {
+	source := &ExchangeTokenSource{client: client, request: *request, now: time.Now}
+	if source.request.BaseURL == "" {
+		source.request.BaseURL = BaseURL
+	}
+
+	if source.request.ApiVersion == "" {
+		source.request.ApiVersion = LowestSupportedVersion
+	}
+
+	return source
+}
+
+// Token implements the whttp.TokenSource interface. Every call exchanges a token, use
+// whttp.NewCachingTokenSource to avoid that.
+func (source *ExchangeTokenSource) Token(ctx context.Context) (*whttp.Token, error) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	response, err := source.client.ExchangeToken(ctx, &source.request)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &whttp.Token{AccessToken: response.AccessToken}
+	if response.ExpiresIn > 0 {
+		token.Expiry = source.now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+	source.request.Token = response.AccessToken
+
+	return token, nil
+}
+
+type (
+	// DebugTokenInfo describes an access token as reported by the debug_token endpoint.
+	DebugTokenInfo struct {
+		AppID               string           `json:"app_id,omitempty"`
+		Type                string           `json:"type,omitempty"`
+		Application         string           `json:"application,omitempty"`
+		DataAccessExpiresAt int64            `json:"data_access_expires_at,omitempty"`
+		ExpiresAt           int64            `json:"expires_at,omitempty"`
+		IsValid             bool             `json:"is_valid"`
+		IssuedAt            int64            `json:"issued_at,omitempty"`
+		Scopes              []string         `json:"scopes,omitempty"`
+		GranularScopes      []*GranularScope `json:"granular_scopes,omitempty"`
+		UserID              string           `json:"user_id,omitempty"`
+		Error               *DebugTokenError `json:"error,omitempty"`
+	}
+
+	// GranularScope lists the objects, such as WhatsApp Business Accounts, a scope of the
+	// token applies to. An empty TargetIDs means the scope applies to every object.
+	GranularScope struct {
+		Scope     string   `json:"scope"`
+		TargetIDs []string `json:"target_ids,omitempty"`
+	}
+
+	// DebugTokenError explains why a token is not valid.
+	DebugTokenError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Subcode int    `json:"subcode,omitempty"`
+	}
+)
+
+// Expiry returns the time the token expires at, the zero time if it never expires.
+func (info *DebugTokenInfo) Expiry() time.Time {
+	if info.ExpiresAt == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(info.ExpiresAt, 0)
+}
+
+// HasScope reports whether the token has been granted the scope, for example
+// whatsapp_business_messaging.
+func (info *DebugTokenInfo) HasScope(scope string) bool {
+	for _, s := range info.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanAccess reports whether the scope of the token covers the object with the given id,
+// for example a WhatsApp Business Account ID with the whatsapp_business_management scope.
+func (info *DebugTokenInfo) CanAccess(scope, id string) bool {
+	for _, granular := range info.GranularScopes {
+		if granular.Scope != scope {
+			continue
+		}
+
+		if len(granular.TargetIDs) == 0 {
+			return true
+		}
+
+		for _, target := range granular.TargetIDs {
+			if target == id {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// DebugToken returns the details of inputToken: whether it is valid, when it expires and
+// the scopes and granular permissions it has been granted. The request is authorized with
+// rtx.Bearer or rtx.TokenSource, usually an app access token or inputToken itself.
+//
+//	curl -X GET "https://graph.facebook.com/debug_token?input_token={input-token}" \
+//	  -H "Authorization: Bearer {access-token}"
+func (c *BaseClient) DebugToken(ctx context.Context, rtx *whttp.RequestContext, inputToken string,
+) (*DebugTokenInfo, error) {
+	params := &whttp.Request{
+		Context: &whttp.RequestContext{
+			Name:        "debug token",
+			BaseURL:     rtx.BaseURL,
+			ApiVersion:  rtx.ApiVersion,
+			TokenSource: rtx.TokenSource,
+			Route:       whttp.RootRoute("debug_token"),
+		},
+		Method: http.MethodGet,
+		Bearer: rtx.Bearer,
+		Query:  map[string]string{"input_token": inputToken},
+	}
+
+	var response struct {
+		Data *DebugTokenInfo `json:"data"`
+	}
+
+	if err := c.do(ctx, params, &response); err != nil {
+		return nil, fmt.Errorf("debug token: %w", err)
+	}
+
+	if response.Data == nil {
+		return nil, fmt.Errorf("debug token: %w", ErrNoDataFound)
+	}
+
+	return response.Data, nil
+}
+
+// DebugToken returns the details of the access token used by the client.
+func (client *Client) DebugToken(ctx context.Context, opts ...CallOption) (*DebugTokenInfo, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	token, err := client.tokens.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("debug token: %w", err)
+	}
+
+	config := client.config()
+
+	return client.bc.DebugToken(ctx, &whttp.RequestContext{
+		BaseURL:    config.BaseURL,
+		ApiVersion: config.Version,
+		Bearer:     token.AccessToken,
+	}, token.AccessToken)
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	whttp "github.com/piusalfred/whatsapp/pkg/http"
+)
+
+func TestExchangeTokenSourceAndDebugToken(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v16.0/oauth/access_token":
+			query := r.URL.Query()
+			if query.Get("grant_type") != "fb_exchange_token" || query.Get("fb_exchange_token") != "short" {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"long","token_type":"bearer","expires_in":5184000}`))
+		case "/v16.0/debug_token":
+			if r.Header.Get("Authorization") != "Bearer long" || r.URL.Query().Get("input_token") != "long" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"app_id":"1","is_valid":true,"expires_at":1700000000,` +
+				`"scopes":["whatsapp_business_messaging"],` +
+				`"granular_scopes":[{"scope":"whatsapp_business_messaging","target_ids":["waba-1"]}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	exchange := NewExchangeTokenSource(NewBaseClient(), &ExchangeTokenRequest{
+		BaseURL: server.URL, AppID: "1", AppSecret: "secret", Token: "short",
+	})
+	source := whttp.NewCachingTokenSource(exchange, 24*time.Hour)
+
+	client, err := NewClientWithConfig(&Config{BaseURL: server.URL}, WithTokenSource(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.DebugToken(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.IsValid || !info.HasScope("whatsapp_business_messaging") || info.Expiry().Unix() != 1700000000 {
+		t.Errorf("unexpected token info %+v", info)
+	}
+
+	if !info.CanAccess("whatsapp_business_messaging", "waba-1") || info.CanAccess("whatsapp_business_messaging", "x") {
+		t.Errorf("unexpected granular scopes %+v", info.GranularScopes)
+	}
+}
@@ -21,11 +21,11 @@ package webhooks
 
 import (
 	"bytes"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
 )
 
 // EventListener wraps all the parts needed to listen and respond to incoming events
@@ -73,6 +73,7 @@ type EventListener struct {
 	v       SubscriptionVerifier
 	options *HandlerOptions
 	g       GlobalNotificationHandler
+	metrics *Metrics
 }
 
 type ListenerOption func(*EventListener)
@@ -96,6 +97,16 @@ func NewEventListener(options ...ListenerOption) *EventListener {
 		option(listener)
 	}
 
+	if listener.metrics != nil {
+		// the options are copied, HandlerOptions given with WithHandlerOptions may be shared
+		wrapped := HandlerOptions{}
+		if listener.options != nil {
+			wrapped = *listener.options
+		}
+		wrapped.AfterFunc = listener.metrics.AfterFunc(wrapped.AfterFunc)
+		listener.options = &wrapped
+	}
+
 	return listener
 }
 
@@ -213,6 +224,41 @@ func (ls *EventListener) OnMediaMessage(hook OnMediaMessageHook) {
 	ls.h.OnMediaMessageHook = hook
 }
 
+func (ls *EventListener) OnImageMessage(hook OnImageMessageHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnImageMessageHook = hook
+}
+
+func (ls *EventListener) OnDocumentMessage(hook OnDocumentMessageHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnDocumentMessageHook = hook
+}
+
+func (ls *EventListener) OnVideoMessage(hook OnVideoMessageHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnVideoMessageHook = hook
+}
+
+func (ls *EventListener) OnAudioMessage(hook OnAudioMessageHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnAudioMessageHook = hook
+}
+
+func (ls *EventListener) OnStickerMessage(hook OnStickerMessageHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnStickerMessageHook = hook
+}
+
 func (ls *EventListener) OnNotificationError(hook OnNotificationErrorHook) {
 	if ls.h == nil {
 		ls.h = &Hooks{}
@@ -288,6 +334,16 @@ func WithAfterFunc(afterFunc AfterFunc) ListenerOption {
 	}
 }
 
+// WithCodec sets the codec that decodes the notifications, the default is codec.Default.
+func WithCodec(c codec.Codec) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.Codec = c
+	}
+}
+
 // NotificationHandler returns a http.Handler that can be used to handle the notification.
 func (ls *EventListener) NotificationHandler() http.Handler {
 	return NotificationHandler(ls.h, ls.neh, ls.hef, ls.options)
@@ -310,9 +366,12 @@ func (ls *EventListener) GlobalHandler() http.Handler {
 			return
 		}
 
+		verified := false
 		if ls.options != nil && ls.options.ValidateSignature {
 			signature, _ := ExtractSignatureFromHeader(request.Header)
-			if !ValidateSignature(buff.Bytes(), signature, ls.options.Secret) {
+			verified = ValidateSignature(buff.Bytes(), signature, ls.options.Secret)
+			if !verified {
+				ls.options.logError(request.Context(), "webhook signature validation failed", ErrInvalidSignature)
 				if handleError(
 					request.Context(), writer, request,
 					ls.neh, ErrInvalidSignature) {
@@ -323,15 +382,18 @@ func (ls *EventListener) GlobalHandler() http.Handler {
 
 		// Construct the notification
 		var notification Notification
-		if err := json.NewDecoder(&buff).Decode(&notification); err != nil && !errors.Is(err, io.EOF) {
+		if err := decodeNotification(ls.options, buff.Bytes(), &notification); err != nil {
+			ls.options.logError(request.Context(), "webhook notification decode failed", err)
 			writer.WriteHeader(http.StatusInternalServerError)
 
 			return
 		}
+		ls.options.logNotification(request.Context(), &notification, verified)
 
 		// call the generic handler
 		if err := ls.g(request.Context(), writer, &notification); err != nil {
 			err = fmt.Errorf("%w: %w", ErrOnGenericHandlerFunc, err)
+			ls.options.logError(request.Context(), "webhook notification handler failed", err)
 			if handleError(request.Context(), writer, request, ls.neh, err) {
 				return
 			}
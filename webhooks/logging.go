@@ -0,0 +1,234 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	werrors "github.com/piusalfred/whatsapp/pkg/errors"
+	"github.com/piusalfred/whatsapp/pkg/models"
+	"github.com/piusalfred/whatsapp/pkg/redact"
+)
+
+var (
+	_ slog.LogValuer = (*Notification)(nil)
+	_ slog.LogValuer = (*Message)(nil)
+	_ slog.LogValuer = (*Status)(nil)
+)
+
+// WithLogger makes the listener log to logger: every notification at the debug level and
+// the notifications that fail to decode, fail signature validation or fail in a hook at
+// the warning level. Signatures are never logged, the options can mask phone numbers and
+// message bodies, see the redact package.
+func WithLogger(logger *slog.Logger, options ...redact.Option) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.Logger = logger
+		ls.options.Redactor = redact.New(options...)
+	}
+}
+
+// LogValue returns the notification as a group of attributes, with the changes numbered
+// in order of appearance.
+func (notification *Notification) LogValue() slog.Value {
+	return notification.logValue(nil)
+}
+
+// LogValue returns the message as a group of attributes.
+func (message *Message) LogValue() slog.Value {
+	return message.logValue(nil)
+}
+
+// LogValue returns the status as a group of attributes.
+func (status *Status) LogValue() slog.Value {
+	return status.logValue(nil)
+}
+
+func (notification *Notification) logValue(r *redact.Redactor) slog.Value {
+	if notification == nil {
+		return slog.StringValue("nil")
+	}
+
+	attrs := []slog.Attr{slog.String("object", notification.Object)}
+	index := 0
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change == nil {
+				continue
+			}
+			index++
+			attrs = append(attrs, slog.Attr{
+				Key:   fmt.Sprintf("change.%d", index),
+				Value: changeLogValue(r, entry.ID, change),
+			})
+		}
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func changeLogValue(r *redact.Redactor, entryID string, change *Change) slog.Value {
+	attrs := []slog.Attr{
+		slog.String("entry", entryID),
+		slog.String("field", change.Field),
+	}
+
+	value := change.Value
+	if value == nil {
+		return slog.GroupValue(attrs...)
+	}
+
+	if value.Metadata != nil {
+		attrs = append(attrs, slog.String("phone_number_id", value.Metadata.PhoneNumberID))
+	}
+	for i, message := range value.Messages {
+		attrs = append(attrs, slog.Attr{Key: fmt.Sprintf("message.%d", i+1), Value: message.logValue(r)})
+	}
+	for i, status := range value.Statuses {
+		attrs = append(attrs, slog.Attr{Key: fmt.Sprintf("status.%d", i+1), Value: status.logValue(r)})
+	}
+	if len(value.Errors) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "errors", Value: errorsLogValue(value.Errors)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func (message *Message) logValue(r *redact.Redactor) slog.Value {
+	if message == nil {
+		return slog.StringValue("nil")
+	}
+
+	attrs := []slog.Attr{
+		slog.String("id", message.ID),
+		slog.String("type", message.Type),
+		slog.String("from", r.Phone(message.From)),
+		slog.String("timestamp", message.Timestamp),
+	}
+	if message.Context != nil && message.Context.ID != "" {
+		attrs = append(attrs, slog.String("context_id", message.Context.ID))
+	}
+	if message.Text != nil {
+		attrs = append(attrs, slog.String("text", r.Truncate(r.Text(message.Text.Body))))
+	}
+	if message.Button != nil {
+		attrs = append(attrs, slog.String("button", r.Text(message.Button.Text)))
+	}
+	if message.Interactive != nil && message.Interactive.Type != nil {
+		if reply := message.Interactive.Type.ButtonReply; reply != nil {
+			attrs = append(attrs, slog.String("reply_id", reply.ID))
+		}
+		if reply := message.Interactive.Type.ListReply; reply != nil {
+			attrs = append(attrs, slog.String("reply_id", reply.ID))
+		}
+	}
+	for _, media := range []*models.MediaInfo{
+		message.Audio, message.Document, message.Image, message.Sticker, message.Video,
+	} {
+		if media != nil {
+			attrs = append(attrs, slog.String("media_id", media.ID), slog.String("mime_type", media.MimeType))
+			if media.Caption != "" {
+				attrs = append(attrs, slog.String("caption", r.Truncate(r.Text(media.Caption))))
+			}
+		}
+	}
+	if len(message.Errors) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "errors", Value: errorsLogValue(message.Errors)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func (status *Status) logValue(r *redact.Redactor) slog.Value {
+	if status == nil {
+		return slog.StringValue("nil")
+	}
+
+	attrs := []slog.Attr{
+		slog.String("id", status.ID),
+		slog.String("status", status.StatusValue),
+		slog.String("recipient_id", r.Phone(status.RecipientID)),
+		slog.Int("timestamp", status.Timestamp),
+	}
+	if status.Conversation != nil {
+		attrs = append(attrs, slog.String("conversation", status.Conversation.ID))
+		if status.Conversation.Origin != nil {
+			attrs = append(attrs, slog.String("origin", status.Conversation.Origin.Type))
+		}
+	}
+	if status.Pricing != nil {
+		attrs = append(attrs, slog.String("pricing_category", status.Pricing.Category))
+	}
+	if len(status.Errors) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "errors", Value: errorsLogValue(status.Errors)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func errorsLogValue(errs []*werrors.Error) slog.Value {
+	attrs := make([]slog.Attr, 0, len(errs))
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		attrs = append(attrs, slog.String(fmt.Sprintf("%d", i+1), fmt.Sprintf("%d: %s", err.Code, err.Message)))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// redactedNotification logs a notification with the redactor of the handler.
+type redactedNotification struct {
+	notification *Notification
+	redactor     *redact.Redactor
+}
+
+func (n redactedNotification) LogValue() slog.Value {
+	return n.notification.logValue(n.redactor)
+}
+
+// logNotification logs a decoded notification at the debug level. verified tells whether its
+// signature was checked and found valid, a notification let through after a failed or a
+// skipped check is logged as not verified.
+func (options *HandlerOptions) logNotification(ctx context.Context, notification *Notification, verified bool) {
+	if options == nil || options.Logger == nil || !options.Logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	options.Logger.LogAttrs(ctx, slog.LevelDebug, "webhook notification", slog.Bool("verified", verified),
+		slog.Any("notification", redactedNotification{notification: notification, redactor: options.Redactor}))
+}
+
+// logError logs an error met while handling a notification at the warning level.
+func (options *HandlerOptions) logError(ctx context.Context, msg string, err error) {
+	if options == nil || options.Logger == nil {
+		return
+	}
+
+	options.Logger.LogAttrs(ctx, slog.LevelWarn, msg, slog.String("error", err.Error()))
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piusalfred/whatsapp/pkg/redact"
+)
+
+const loggedNotification = `{"object":"whatsapp_business_account","entry":[{"id":"WABA","changes":[{"value":{` +
+	`"messaging_product":"whatsapp","metadata":{"phone_number_id":"1234"},` +
+	`"messages":[{"from":"255712345678","id":"wamid.1","type":"text","text":{"body":"my pin is 1234"}}],` +
+	`"statuses":[{"id":"wamid.2","recipient_id":"255787654321","status":"read"}]},"field":"messages"}]}]}`
+
+func TestNotificationLogValue(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := decodeNotification(nil, []byte(loggedNotification), &notification); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		redactor *redact.Redactor
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "not masked",
+			contains: []string{"wamid.1", "255712345678", "my pin is 1234", "status=read", "phone_number_id=1234"},
+		},
+		{
+			name:     "masked",
+			redactor: redact.New(redact.MaskPhoneNumbers(), redact.MaskMessageBodies()),
+			contains: []string{"wamid.1", "********5678", "********4321", redact.Redacted},
+			excludes: []string{"255712345678", "255787654321", "my pin"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := redactedNotification{notification: &notification, redactor: tt.redactor}.LogValue().String()
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("LogValue() = %s, want it to contain %s", got, s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(got, s) {
+					t.Errorf("LogValue() = %s, want it not to contain %s", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestListenerLogger(t *testing.T) {
+	t.Parallel()
+	skip := func(context.Context, *http.Request, error) *NotificationErrHandlerResponse {
+		return &NotificationErrHandlerResponse{Skip: true}
+	}
+
+	tests := []struct {
+		name     string
+		neh      NotificationErrorHandler
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "rejected signature",
+			neh:      NoOpNotificationErrorHandler,
+			contains: []string{"webhook signature validation failed"},
+			excludes: []string{"msg=\"webhook notification\"", "********5678"},
+		},
+		{
+			name: "skipped signature error",
+			neh:  skip,
+			contains: []string{
+				"webhook signature validation failed", "msg=\"webhook notification\" verified=false", "********5678",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			listener := NewEventListener(
+				WithHandlerOptions(&HandlerOptions{ValidateSignature: true, Secret: "secret"}),
+				WithLogger(logger, redact.MaskPhoneNumbers()),
+				WithNotificationErrorHandler(tt.neh),
+			)
+
+			request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(loggedNotification))
+			request.Header.Set("X-Hub-Signature-256", "sha256=0123456789abcdef")
+			request = request.WithContext(context.TODO())
+			listener.NotificationHandler().ServeHTTP(httptest.NewRecorder(), request)
+
+			logged := buf.String()
+			for _, want := range tt.contains {
+				if !strings.Contains(logged, want) {
+					t.Errorf("log %s does not contain %s", logged, want)
+				}
+			}
+
+			for _, unwanted := range append(tt.excludes, "0123456789abcdef", "255712345678") {
+				if strings.Contains(logged, unwanted) {
+					t.Errorf("log %s contains %s", logged, unwanted)
+				}
+			}
+		})
+	}
+}
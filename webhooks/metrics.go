@@ -0,0 +1,166 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/piusalfred/whatsapp/pkg/metrics"
+)
+
+const (
+	// MetricNotifications counts the notifications by field, message type and status
+	// value. A message is counted with its type and an empty status, a status with the
+	// "status" type and its value.
+	MetricNotifications = "whatsapp_webhook_notifications_total"
+
+	// MetricHookErrors counts the notifications whose hooks or BeforeFunc failed.
+	MetricHookErrors = "whatsapp_webhook_hook_errors_total"
+
+	// MetricHookDuration is the duration of the handling of a notification, from its
+	// reception by NotificationHandler to AfterFunc, which includes BeforeFunc and the hooks.
+	MetricHookDuration = "whatsapp_webhook_hook_duration_seconds"
+
+	// MetricSignatureFailures counts the notifications with an invalid signature.
+	MetricSignatureFailures = "whatsapp_webhook_signature_failures_total"
+)
+
+// Metrics records the notifications handled by a NotificationHandler in a metrics.Registry
+// through the AfterFunc of its HandlerOptions.
+type Metrics struct {
+	notifications     *metrics.CounterVec
+	hookErrors        *metrics.CounterVec
+	durations         *metrics.HistogramVec
+	signatureFailures *metrics.CounterVec
+}
+
+// NewMetrics creates the webhook metrics in registry.
+func NewMetrics(registry *metrics.Registry) *Metrics {
+	return &Metrics{
+		notifications: registry.Counter(MetricNotifications, "Webhook notifications received.",
+			"field", "message_type", "status"),
+		hookErrors: registry.Counter(MetricHookErrors, "Webhook notifications whose hooks failed.", "field"),
+		durations: registry.Histogram(MetricHookDuration, "Duration of the handling of webhook notifications.",
+			nil, "field"),
+		signatureFailures: registry.Counter(MetricSignatureFailures,
+			"Webhook notifications with an invalid signature."),
+	}
+}
+
+// WithMetrics records the notifications in registry, see Metrics. The AfterFunc of the
+// listener is wrapped by NewEventListener once all the options are applied, so WithMetrics
+// can come before or after WithAfterFunc and WithHandlerOptions. GlobalHandler does not
+// call it and is not instrumented.
+func WithMetrics(registry *metrics.Registry) ListenerOption {
+	m := NewMetrics(registry)
+
+	return func(ls *EventListener) {
+		ls.metrics = m
+	}
+}
+
+// AfterFunc returns an AfterFunc that calls next, when it is not nil, and then records the
+// notification and the errors it was handled with. Notifications with an invalid signature
+// are counted as signature failures and not as notifications, even when the failure was
+// skipped by the NotificationErrorHandler.
+func (m *Metrics) AfterFunc(next AfterFunc) AfterFunc {
+	return func(ctx context.Context, notification *Notification, err error) {
+		if next != nil {
+			next(ctx, notification, err)
+		}
+
+		field := firstField(notification)
+		if errors.Is(err, ErrInvalidSignature) {
+			m.signatureFailures.Inc()
+		} else {
+			m.record(notification)
+		}
+
+		if errors.Is(err, ErrOnAttachNotificationHooks) || errors.Is(err, ErrOnBeforeFuncHook) {
+			m.hookErrors.Inc(field)
+		}
+
+		if start, ok := receivedAt(ctx); ok {
+			m.durations.Observe(time.Since(start).Seconds(), field)
+		}
+	}
+}
+
+// record counts the messages and the statuses of notification.
+func (m *Metrics) record(notification *Notification) {
+	if notification == nil {
+		return
+	}
+
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change != nil {
+				m.recordChange(change)
+			}
+		}
+	}
+}
+
+// firstField returns the field of the first change of notification, the label of the
+// hook metrics.
+func firstField(notification *Notification) string {
+	if notification == nil {
+		return ""
+	}
+
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change != nil {
+				return change.Field
+			}
+		}
+	}
+
+	return ""
+}
+
+func (m *Metrics) recordChange(change *Change) {
+	value := change.Value
+	if value == nil || len(value.Messages)+len(value.Statuses) == 0 {
+		m.notifications.Inc(change.Field, "", "")
+
+		return
+	}
+
+	for _, message := range value.Messages {
+		if message != nil {
+			m.notifications.Inc(change.Field, message.Type, "")
+		}
+	}
+
+	for _, status := range value.Statuses {
+		if status != nil {
+			m.notifications.Inc(change.Field, "status", status.StatusValue)
+		}
+	}
+}
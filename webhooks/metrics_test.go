@@ -0,0 +1,113 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piusalfred/whatsapp/pkg/metrics"
+)
+
+func TestListenerMetrics(t *testing.T) {
+	t.Parallel()
+	registry := metrics.NewRegistry()
+	var after int
+	// WithMetrics comes first, the AfterFunc set after it is still wrapped
+	listener := NewEventListener(
+		WithMetrics(registry),
+		WithHandlerOptions(&HandlerOptions{}),
+		WithAfterFunc(func(context.Context, *Notification, error) { after++ }),
+		WithNotificationErrorHandler(NoOpNotificationErrorHandler),
+		WithHooks(&Hooks{
+			OnTextMessageHook: func(context.Context, *NotificationContext, *MessageContext, *Text) error {
+				return nil
+			},
+			OnMessageStatusChangeHook: func(context.Context, *NotificationContext, *Status) error {
+				return errors.New("status hook failed")
+			},
+		}),
+	)
+
+	serve := func(handler http.Handler, body string) {
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+		handler.ServeHTTP(httptest.NewRecorder(), request.WithContext(context.TODO()))
+	}
+	serve(listener.NotificationHandler(), loggedNotification)
+
+	// a second handler validating signatures
+	options := &HandlerOptions{ValidateSignature: true, Secret: "secret"}
+	m := NewMetrics(registry)
+	options.AfterFunc = m.AfterFunc(nil)
+	serve(NotificationHandler(nil, NoOpNotificationErrorHandler, NoOpHooksErrorHandler, options), loggedNotification)
+
+	// a third one skipping the signature failure, whose hooks then fail
+	var handled error
+	skipping := &HandlerOptions{
+		ValidateSignature: true,
+		Secret:            "secret",
+		AfterFunc:         m.AfterFunc(func(_ context.Context, _ *Notification, err error) { handled = err }),
+	}
+	skip := func(context.Context, *http.Request, error) *NotificationErrHandlerResponse {
+		return &NotificationErrHandlerResponse{Skip: true}
+	}
+	failing := &Hooks{
+		OnTextMessageHook: func(context.Context, *NotificationContext, *MessageContext, *Text) error {
+			return nil
+		},
+		OnMessageStatusChangeHook: func(context.Context, *NotificationContext, *Status) error {
+			return errors.New("status hook failed")
+		},
+	}
+	serve(NotificationHandler(failing, skip, NoOpHooksErrorHandler, skipping), loggedNotification)
+
+	if !errors.Is(handled, ErrInvalidSignature) || !errors.Is(handled, ErrOnAttachNotificationHooks) {
+		t.Errorf("AfterFunc received %v, want the signature and the hooks errors", handled)
+	}
+
+	if after != 1 {
+		t.Errorf("the AfterFunc set after WithMetrics was called %d times, want 1", after)
+	}
+
+	notifications := registry.Counter(MetricNotifications, "", "field", "message_type", "status")
+	if got := notifications.Value("messages", "text", ""); got != 1 {
+		t.Errorf("text messages = %v, want 1", got)
+	}
+
+	if got := notifications.Value("messages", "status", "read"); got != 1 {
+		t.Errorf("read statuses = %v, want 1", got)
+	}
+
+	if got := registry.Counter(MetricHookErrors, "", "field").Value("messages"); got != 2 {
+		t.Errorf("hook errors = %v, want 2", got)
+	}
+
+	if got := registry.Counter(MetricSignatureFailures, "").Value(); got != 2 {
+		t.Errorf("signature failures = %v, want 2", got)
+	}
+
+	if got := registry.Histogram(MetricHookDuration, "", nil, "field").Count("messages"); got != 3 {
+		t.Errorf("durations = %v, want 3", got)
+	}
+}
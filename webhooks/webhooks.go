@@ -25,15 +25,18 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/piusalfred/whatsapp/pkg/codec"
 	werrors "github.com/piusalfred/whatsapp/pkg/errors"
 	"github.com/piusalfred/whatsapp/pkg/models"
+	"github.com/piusalfred/whatsapp/pkg/redact"
 )
 
 // PayloadMaxSize is the maximum size of the payload that can be sent to the webhook.
@@ -141,9 +144,31 @@ type (
 		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, system *System) error
 
 	// OnMediaMessageHook is a hook that is called when a media message is received. This is when Message.Type is
-	// image, audio, video or document or sticker.
+	// image, audio, video or document or sticker. mediaType tells which one it is and media is the matching
+	// Message.Image, Message.Audio, Message.Video, Message.Document or Message.Sticker.
+	//
+	// It is called for every media message, after the hook for its kind, like OnImageMessageHook, if that is set.
+	// It is not called when the hook for the kind returns an error.
 	OnMediaMessageHook func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
-		media *models.MediaInfo) error
+		mediaType MessageType, media *models.MediaInfo) error
+
+	OnImageMessageHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, image *models.MediaInfo) error
+	OnDocumentMessageHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, document *models.MediaInfo) error
+	OnVideoMessageHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, video *models.MediaInfo) error
+
+	// OnAudioMessageHook is called when an audio message is received. voice is true when the
+	// audio is a voice note recorded in WhatsApp rather than an audio file.
+	OnAudioMessageHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, audio *models.MediaInfo, voice bool) error
+
+	// OnStickerMessageHook is called when a sticker message is received. animated is true for
+	// animated stickers.
+	OnStickerMessageHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, sticker *models.MediaInfo,
+		animated bool) error
 
 	// OnNotificationErrorHook is a hook that is called when an error is received in a notification.
 	// This is called when an error is received in a notification. This is not called when an error
@@ -184,6 +209,11 @@ type (
 		OnCustomerIDChangeHook    OnCustomerIDChangeMessageHook
 		OnSystemMessageHook       OnSystemMessageHook
 		OnMediaMessageHook        OnMediaMessageHook
+		OnImageMessageHook        OnImageMessageHook
+		OnDocumentMessageHook     OnDocumentMessageHook
+		OnVideoMessageHook        OnVideoMessageHook
+		OnAudioMessageHook        OnAudioMessageHook
+		OnStickerMessageHook      OnStickerMessageHook
 		OnNotificationErrorHook   OnNotificationErrorHook
 		OnMessageStatusChangeHook OnMessageStatusChangeHook
 		OnMessageReceivedHook     OnMessageReceivedHook
@@ -270,19 +300,25 @@ type (
 	// To check db availability etc.
 	BeforeFunc func(ctx context.Context, notification *Notification) error
 
-	// AfterFunc is a function that is called after a notification is processed. It also receives the error
-	// that occurred during processing. There can be a number of use cases where the AfterFunc is useful.
+	// AfterFunc is a function that is called after a notification is processed. It also receives the errors
+	// that occurred during processing, joined with errors.Join, including the ones skipped by the
+	// NotificationErrorHandler. There can be a number of use cases where the AfterFunc is useful.
 	// For example, you can use it to log the error or send a notification to a monitoring service. Or have the
 	// instrumentation logic put here.
 	AfterFunc func(ctx context.Context, notification *Notification, err error)
 
 	// HandlerOptions is a struct that contains the options that can be passed to the NotificationHandler. Note that
 	// the options are optional. NotificationHandler can be used without any options set.
+	// Codec decodes the notifications, codec.Default is used when it is nil. Logger, when set,
+	// logs the notifications with Redactor, see WithLogger.
 	HandlerOptions struct {
 		BeforeFunc        BeforeFunc
 		AfterFunc         AfterFunc
 		ValidateSignature bool
 		Secret            string
+		Codec             codec.Codec
+		Logger            *slog.Logger
+		Redactor          *redact.Redactor
 	}
 
 	// VerificationRequest contains details sent by the whatsapp server during the verification process.
@@ -480,7 +516,7 @@ func attachHooksToMessage(ctx context.Context, nctx *NotificationContext, hooks
 		return hooks.OnButtonMessageHook(ctx, nctx, mctx, message.Button)
 
 	case AudioMessageType, VideoMessageType, ImageMessageType, DocumentMessageType, StickerMessageType:
-		return hooks.OnMediaMessageHook(ctx, nctx, mctx, message.Audio)
+		return attachHooksToMediaMessage(ctx, nctx, mctx, hooks, messageType, message)
 
 	case InteractiveMessageType:
 		return hooks.OnInteractiveMessageHook(ctx, nctx, mctx, message.Interactive)
@@ -526,6 +562,83 @@ func attachHooksToMessage(ctx context.Context, nctx *NotificationContext, hooks
 	}
 }
 
+// MessageMedia returns the media of a media message and its type. It returns nil if the
+// message is not an image, audio, video, document or sticker message.
+func MessageMedia(message *Message) (MessageType, *models.MediaInfo) {
+	if message == nil {
+		return "", nil
+	}
+
+	switch messageType := ParseMessageType(message.Type); messageType {
+	case ImageMessageType:
+		return messageType, message.Image
+	case AudioMessageType:
+		return messageType, message.Audio
+	case VideoMessageType:
+		return messageType, message.Video
+	case DocumentMessageType:
+		return messageType, message.Document
+	case StickerMessageType:
+		return messageType, message.Sticker
+	default:
+		return messageType, nil
+	}
+}
+
+// IsVoiceNote reports whether an audio is a voice note. Voice notes are flagged by the
+// voice field, older notifications are recognised by their ogg/opus mime type.
+func IsVoiceNote(audio *models.MediaInfo) bool {
+	if audio == nil {
+		return false
+	}
+
+	if audio.Voice {
+		return true
+	}
+
+	mimeType := strings.ToLower(strings.ReplaceAll(audio.MimeType, " ", ""))
+
+	return strings.HasPrefix(mimeType, "audio/ogg") && strings.Contains(mimeType, "codecs=opus")
+}
+
+// attachHooksToMediaMessage calls the hook for the kind of media received if it is set and then
+// OnMediaMessageHook if it is set. OnMediaMessageHook is not called when the kind hook returns an error.
+func attachHooksToMediaMessage(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	hooks *Hooks, messageType MessageType, message *Message,
+) error {
+	_, media := MessageMedia(message)
+
+	if err := attachMediaKindHook(ctx, nctx, mctx, hooks, messageType, media); err != nil {
+		return err
+	}
+
+	if hooks.OnMediaMessageHook != nil {
+		return hooks.OnMediaMessageHook(ctx, nctx, mctx, messageType, media)
+	}
+
+	return nil
+}
+
+// attachMediaKindHook calls the hook for the kind of media received if it is set.
+func attachMediaKindHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	hooks *Hooks, messageType MessageType, media *models.MediaInfo,
+) error {
+	switch {
+	case messageType == ImageMessageType && hooks.OnImageMessageHook != nil:
+		return hooks.OnImageMessageHook(ctx, nctx, mctx, media)
+	case messageType == DocumentMessageType && hooks.OnDocumentMessageHook != nil:
+		return hooks.OnDocumentMessageHook(ctx, nctx, mctx, media)
+	case messageType == VideoMessageType && hooks.OnVideoMessageHook != nil:
+		return hooks.OnVideoMessageHook(ctx, nctx, mctx, media)
+	case messageType == AudioMessageType && hooks.OnAudioMessageHook != nil:
+		return hooks.OnAudioMessageHook(ctx, nctx, mctx, media, IsVoiceNote(media))
+	case messageType == StickerMessageType && hooks.OnStickerMessageHook != nil:
+		return hooks.OnStickerMessageHook(ctx, nctx, mctx, media, media != nil && media.Animated)
+	default:
+		return nil
+	}
+}
+
 var (
 	ErrOnBeforeFuncHook          = errors.New("error on before func hook")
 	ErrOnAttachNotificationHooks = errors.New("error during attaching hooks to a notification")
@@ -550,7 +663,7 @@ func NotificationHandler(
 			err          error
 			notification = &Notification{}
 		)
-		ctx := request.Context()
+		ctx := withReceivedAt(request.Context(), time.Now())
 
 		defer func() {
 			buff.Reset()
@@ -568,33 +681,45 @@ func NotificationHandler(
 		}
 		request.Body = io.NopCloser(&buff)
 
-		if err = json.NewDecoder(&buff).Decode(notification); err != nil && !errors.Is(err, io.EOF) {
+		if err = decodeNotification(options, buff.Bytes(), notification); err != nil {
+			options.logError(ctx, "webhook notification decode failed", err)
 			writer.WriteHeader(http.StatusInternalServerError)
 
 			return
 		}
 
+		// the errors skipped by neh are kept, so that AfterFunc receives all of them
 		if options != nil && options.BeforeFunc != nil {
 			if bfe := options.BeforeFunc(ctx, notification); bfe != nil {
-				err = fmt.Errorf("%w: %w", ErrOnBeforeFuncHook, bfe)
-				if handleError(ctx, writer, request, neh, err) {
+				bfe = fmt.Errorf("%w: %w", ErrOnBeforeFuncHook, bfe)
+				err = errors.Join(err, bfe)
+				options.logError(ctx, "webhook notification rejected", bfe)
+				if handleError(ctx, writer, request, neh, bfe) {
 					return
 				}
 			}
 		}
 
+		verified := false
 		if options != nil && options.ValidateSignature {
 			signature, _ := ExtractSignatureFromHeader(request.Header)
-			if !ValidateSignature(buff.Bytes(), signature, options.Secret) {
+			verified = ValidateSignature(buff.Bytes(), signature, options.Secret)
+			if !verified {
+				err = errors.Join(err, ErrInvalidSignature)
+				options.logError(ctx, "webhook signature validation failed", ErrInvalidSignature)
 				if handleError(ctx, writer, request, neh, ErrInvalidSignature) {
 					return
 				}
 			}
 		}
+		options.logNotification(ctx, notification, verified)
+
 		// Apply the Hooks
-		if err = AttachHooksToNotification(ctx, notification, hooks, heh); err != nil {
-			err = fmt.Errorf("%w: %w", ErrOnAttachNotificationHooks, err)
-			if handleError(ctx, writer, request, neh, err) {
+		if hooksErr := AttachHooksToNotification(ctx, notification, hooks, heh); hooksErr != nil {
+			hooksErr = fmt.Errorf("%w: %w", ErrOnAttachNotificationHooks, hooksErr)
+			err = errors.Join(err, hooksErr)
+			options.logError(ctx, "webhook notification hooks failed", hooksErr)
+			if handleError(ctx, writer, request, neh, hooksErr) {
 				return
 			}
 		}
@@ -603,6 +728,39 @@ func NotificationHandler(
 	})
 }
 
+type receivedAtKey struct{}
+
+// withReceivedAt returns a context carrying the time the notification was received.
+func withReceivedAt(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, receivedAtKey{}, t)
+}
+
+// receivedAt returns the time NotificationHandler received the notification handled with ctx.
+func receivedAt(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(receivedAtKey{}).(time.Time)
+
+	return t, ok
+}
+
+// decodeNotification decodes data into notification with the codec of options. An empty
+// body is not an error, the notification is left as it is.
+func decodeNotification(options *HandlerOptions, data []byte, notification *Notification) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	var c codec.Codec
+	if options != nil {
+		c = options.Codec
+	}
+
+	if err := codec.OrDefault(c).Unmarshal(data, notification); err != nil {
+		return fmt.Errorf("decode notification: %w", err)
+	}
+
+	return nil
+}
+
 func handleError(ctx context.Context, writer http.ResponseWriter, request *http.Request,
 	neh NotificationErrorHandler, err error,
 ) bool {
@@ -22,10 +22,15 @@ package webhooks
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+
+	"github.com/piusalfred/whatsapp/pkg/codec"
+	"github.com/piusalfred/whatsapp/pkg/models"
 )
 
 func Example_newEventListener() {
@@ -54,6 +59,11 @@ func Example_newEventListener() {
 			OnCustomerIDChangeHook:    nil,
 			OnSystemMessageHook:       nil,
 			OnMediaMessageHook:        nil,
+			OnImageMessageHook:        nil,
+			OnDocumentMessageHook:     nil,
+			OnVideoMessageHook:        nil,
+			OnAudioMessageHook:        nil,
+			OnStickerMessageHook:      nil,
 			OnNotificationErrorHook:   nil,
 			OnMessageStatusChangeHook: nil,
 			OnMessageReceivedHook:     nil,
@@ -175,6 +185,7 @@ func TestNotificationHandler_Options(t *testing.T) {
 		Secret            string
 		Hooks             *Hooks
 		Body              []byte
+		Codec             codec.Codec
 	}
 
 	testcases := []struct {
@@ -208,6 +219,22 @@ func TestNotificationHandler_Options(t *testing.T) {
 			},
 			wantStatus: http.StatusOK,
 		},
+		{
+			name: "strict codec with known fields",
+			fields: fields{
+				Codec: codec.Strict(),
+				Body:  []byte(`{"object":"whatsapp_business_account","entry":[{"id":"ID","changes":[]}]}`),
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "strict codec with an unknown field",
+			fields: fields{
+				Codec: codec.Strict(),
+				Body:  []byte(`{"object":"whatsapp_business_account","unknown":true,"entry":[]}`),
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
 	}
 
 	for _, tt := range testcases {
@@ -220,6 +247,7 @@ func TestNotificationHandler_Options(t *testing.T) {
 				AfterFunc:         tt.fields.AfterFunc,
 				ValidateSignature: tt.fields.ValidateSignature,
 				Secret:            tt.fields.Secret,
+				Codec:             tt.fields.Codec,
 			}
 			h := NotificationHandler(hooks, NoOpNotificationErrorHandler, NoOpHooksErrorHandler, options)
 
@@ -285,3 +313,109 @@ func TestExtractSignatureFromHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestAttachHooksToMediaMessage(t *testing.T) {
+	t.Parallel()
+	var (
+		generic  []MessageType
+		images   []string
+		voice    bool
+		animated bool
+	)
+	hooks := &Hooks{
+		OnMediaMessageHook: func(_ context.Context, _ *NotificationContext, _ *MessageContext,
+			mediaType MessageType, media *models.MediaInfo,
+		) error {
+			if media == nil {
+				return fmt.Errorf("nil media for %s", mediaType)
+			}
+			generic = append(generic, mediaType)
+
+			return nil
+		},
+		OnImageMessageHook: func(_ context.Context, _ *NotificationContext, _ *MessageContext,
+			image *models.MediaInfo,
+		) error {
+			images = append(images, image.ID)
+
+			return nil
+		},
+		OnAudioMessageHook: func(_ context.Context, _ *NotificationContext, _ *MessageContext,
+			_ *models.MediaInfo, v bool,
+		) error {
+			voice = v
+
+			return nil
+		},
+		OnStickerMessageHook: func(_ context.Context, _ *NotificationContext, _ *MessageContext,
+			_ *models.MediaInfo, a bool,
+		) error {
+			animated = a
+
+			return nil
+		},
+	}
+
+	messages := []*Message{
+		{Type: "image", Image: &models.MediaInfo{ID: "image-1"}},
+		{Type: "video", Video: &models.MediaInfo{ID: "video-1"}},
+		{Type: "document", Document: &models.MediaInfo{ID: "document-1", Filename: "invoice.pdf"}},
+		{Type: "audio", Audio: &models.MediaInfo{ID: "audio-1", MimeType: "audio/ogg; codecs=opus"}},
+		{Type: "sticker", Sticker: &models.MediaInfo{ID: "sticker-1", Animated: true}},
+	}
+
+	for _, message := range messages {
+		if err := attachHooksToMessage(context.TODO(), &NotificationContext{}, hooks, message); err != nil {
+			t.Fatalf("attachHooksToMessage(%s) error = %v", message.Type, err)
+		}
+	}
+
+	if len(images) != 1 || images[0] != "image-1" {
+		t.Errorf("expected image hook to receive image-1, got %v", images)
+	}
+
+	want := []MessageType{
+		ImageMessageType, VideoMessageType, DocumentMessageType, AudioMessageType, StickerMessageType,
+	}
+	if !reflect.DeepEqual(generic, want) {
+		t.Errorf("expected generic media hook for %v, got %v", want, generic)
+	}
+
+	if !voice {
+		t.Errorf("expected audio to be detected as a voice note")
+	}
+
+	if !animated {
+		t.Errorf("expected sticker to be animated")
+	}
+}
+
+func TestAttachHooksToMediaMessageKindError(t *testing.T) {
+	t.Parallel()
+	errKind := errors.New("image hook failed")
+	called := false
+	hooks := &Hooks{
+		OnMediaMessageHook: func(_ context.Context, _ *NotificationContext, _ *MessageContext,
+			_ MessageType, _ *models.MediaInfo,
+		) error {
+			called = true
+
+			return nil
+		},
+		OnImageMessageHook: func(_ context.Context, _ *NotificationContext, _ *MessageContext,
+			_ *models.MediaInfo,
+		) error {
+			return errKind
+		},
+	}
+
+	message := &Message{Type: "image", Image: &models.MediaInfo{ID: "image-1"}}
+	err := attachHooksToMessage(context.TODO(), &NotificationContext{}, hooks, message)
+	if !errors.Is(err, errKind) {
+		t.Fatalf("attachHooksToMessage() error = %v, want %v", err, errKind)
+	}
+
+	if called {
+		t.Errorf("expected generic media hook not to be called after the image hook failed")
+	}
+}
@@ -27,10 +27,15 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/piusalfred/whatsapp/pkg/codec"
 	whttp "github.com/piusalfred/whatsapp/pkg/http"
+	"github.com/piusalfred/whatsapp/pkg/metrics"
 	"github.com/piusalfred/whatsapp/pkg/models"
+	"github.com/piusalfred/whatsapp/pkg/redact"
 )
 
 var (
@@ -130,9 +135,18 @@ type (
 	// It is used to create a new whatsapp client for a single user. Uses the BaseClient
 	// to make requests to the whatsapp api. If you want a client that's flexible and can
 	// make requests to the whatsapp api for different users, use the TransparentClient.
+	//
+	// The configuration can be replaced while the client is in use, see UpdateConfig and
+	// WatchConfig. Every call reads a single snapshot of it.
 	Client struct {
-		bc     *BaseClient
-		config *Config
+		bc            *BaseClient
+		tokens        whttp.TokenSource
+		configuration atomic.Pointer[Config]
+		mu            sync.Mutex
+		changeHooks   []OnConfigChangeFunc
+		logger        *slog.Logger
+		logOptions    []redact.Option
+		metrics       *metrics.Registry
 	}
 
 	ClientOption func(*Client)
@@ -255,7 +269,9 @@ type (
 		BaseURL                string
 		AccessToken            string
 		PhoneNumberID          string
+		BusinessAccountID      string
 		ApiVersion             string //nolint: revive,stylecheck
+		TokenSource            whttp.TokenSource
 		Recipient              string
 		TemplateLanguageCode   string
 		TemplateLanguagePolicy string
@@ -307,6 +323,9 @@ type (
 	   Example: ETag: "33a64df5". This header is ignored unless both Cache-Control and Last-Modified headers
 	   are not included in the Resp. In this case, we will cache the asset according to our own, internal
 	   logic (which we do not disclose).
+
+	   These headers are read from the response of the server hosting the media, not from the send
+	   request. Use MediaHandler to serve media with them set.
 	*/
 	CacheOptions struct {
 		CacheControl string `json:"cache_control,omitempty"`
@@ -316,18 +335,20 @@ type (
 	}
 
 	SendMediaRequest struct {
-		BaseURL       string
-		AccessToken   string
-		PhoneNumberID string
-		ApiVersion    string //nolint: revive,stylecheck
-		Recipient     string
-		Type          MediaType
-		MediaID       string
-		MediaLink     string
-		Caption       string
-		Filename      string
-		Provider      string
-		CacheOptions  *CacheOptions
+		BaseURL           string
+		AccessToken       string
+		PhoneNumberID     string
+		BusinessAccountID string
+		ApiVersion        string //nolint: revive,stylecheck
+		TokenSource       whttp.TokenSource
+		Recipient         string
+		Type              MediaType
+		MediaID           string
+		MediaLink         string
+		Caption           string
+		Filename          string
+		Provider          string
+		CacheOptions      *CacheOptions
 	}
 )
 
@@ -337,6 +358,16 @@ func WithBaseClient(base *BaseClient) ClientOption {
 	}
 }
 
+// WithTokenSource makes the client take the access token of every request from source
+// instead of Config.AccessToken.
+func WithTokenSource(source whttp.TokenSource) ClientOption {
+	return func(client *Client) {
+		if source != nil {
+			client.tokens = source
+		}
+	}
+}
+
 func NewClient(reader ConfigReader, options ...ClientOption) (*Client, error) {
 	config, err := reader.Read(context.Background())
 	if err != nil {
@@ -350,19 +381,22 @@ func NewClientWithConfig(config *Config, options ...ClientOption) (*Client, erro
 	if config == nil {
 		return nil, ErrConfigNil
 	}
+	base := NewBaseClient()
 	client := &Client{
-		bc:     NewBaseClient(),
-		config: config,
+		bc: base,
 	}
+	client.tokens = configTokenSource{client: client}
 
-	if client.config.BaseURL == "" {
-		client.config.BaseURL = BaseURL
+	if config.BaseURL == "" {
+		config.BaseURL = BaseURL
 	}
 
-	if client.config.Version == "" {
-		client.config.Version = LowestSupportedVersion
+	if config.Version == "" {
+		config.Version = LowestSupportedVersion
 	}
 
+	client.configuration.Store(config)
+
 	for _, option := range options {
 		if option == nil {
 			// skip nil options
@@ -371,6 +405,22 @@ func NewClientWithConfig(config *Config, options ...ClientOption) (*Client, erro
 		option(client)
 	}
 
+	// a BaseClient set with WithBaseClient may be shared by clients with different app
+	// secrets, its owner has to add the whttp.AppSecretProofHook, the logger and the metrics.
+	if client.bc == base {
+		base.base.AppendRequestHooks(whttp.AppSecretProofHookFunc(func(context.Context) string {
+			return client.config().AppSecret
+		}))
+
+		if client.metrics != nil {
+			base.setMetrics(client.metrics)
+		}
+
+		if client.logger != nil {
+			base.setLogger(client.logger, client.logOptions...)
+		}
+	}
+
 	return client, nil
 }
 
@@ -378,8 +428,10 @@ func NewClientWithConfig(config *Config, options ...ClientOption) (*Client, erro
 // You can send any message as a reply to a previous message in a conversation by including the previous
 // message's ID set as Ctx in ReplyRequest. The recipient will receive the new message along with a
 // contextual bubble that displays the previous message's content.
-func (client *Client) Reply(ctx context.Context, request *ReplyRequest,
+func (client *Client) Reply(ctx context.Context, request *ReplyRequest, opts ...CallOption,
 ) (*ResponseMessage, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	if request == nil {
 		return nil, fmt.Errorf("reply request is nil: %w", ErrBadRequestFormat)
 	}
@@ -388,11 +440,13 @@ func (client *Client) Reply(ctx context.Context, request *ReplyRequest,
 		return nil, fmt.Errorf("reply: %w", err)
 	}
 	reqCtx := &whttp.RequestContext{
-		Name:          "reply to message",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Endpoints:     []string{MessageEndpoint},
+		TokenSource:       client.tokens,
+		Name:              "reply to message",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, MessageEndpoint),
 	}
 
 	req := &whttp.Request{
@@ -400,13 +454,13 @@ func (client *Client) Reply(ctx context.Context, request *ReplyRequest,
 		Method:  http.MethodPost,
 		Headers: map[string]string{"Content-Type": "application/json"},
 		Query:   nil,
-		Bearer:  client.config.AccessToken,
+		Bearer:  config.AccessToken,
 		Form:    nil,
 		Payload: payload,
 	}
 
 	var message ResponseMessage
-	err = client.bc.base.Do(ctx, req, &message)
+	err = client.bc.do(ctx, req, &message)
 	if err != nil {
 		return nil, fmt.Errorf("reply: %w", err)
 	}
@@ -439,8 +493,9 @@ func formatReplyPayload(options *ReplyRequest) ([]byte, error) {
 
 // SendText sends a text message to a WhatsApp Business Account.
 func (client *Client) SendText(ctx context.Context, recipient string,
-	message *TextMessage,
+	message *TextMessage, opts ...CallOption,
 ) (*ResponseMessage, error) {
+	ctx = client.withCallOptions(ctx, opts)
 	text := &models.Message{
 		Product:       MessagingProduct,
 		To:            recipient,
@@ -500,7 +555,10 @@ func (client *Client) SendText(ctx context.Context, recipient string,
 //	      "id": "wamid.ID",
 //	    }]
 //	}
-func (client *Client) React(ctx context.Context, recipient string, msg *ReactMessage) (*ResponseMessage, error) {
+func (client *Client) React(ctx context.Context, recipient string, msg *ReactMessage,
+	opts ...CallOption,
+) (*ResponseMessage, error) {
+	ctx = client.withCallOptions(ctx, opts)
 	reaction := &models.Message{
 		Product: MessagingProduct,
 		To:      recipient,
@@ -520,9 +578,13 @@ func (client *Client) React(ctx context.Context, recipient string, msg *ReactMes
 }
 
 // SendContacts sends a contact message. Contacts can be easily built using the models.NewContact() function.
-func (client *Client) SendContacts(ctx context.Context, recipient string, contacts []*models.Contact) (
+func (client *Client) SendContacts(ctx context.Context, recipient string, contacts []*models.Contact,
+	opts ...CallOption,
+) (
 	*ResponseMessage, error,
 ) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	contact := &models.Message{
 		Product:       MessagingProduct,
 		To:            recipient,
@@ -532,12 +594,14 @@ func (client *Client) SendContacts(ctx context.Context, recipient string, contac
 	}
 
 	req := &whttp.RequestContext{
-		Name:          "send contacts",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Bearer:        client.config.AccessToken,
-		Endpoints:     []string{MessageEndpoint},
+		TokenSource:       client.tokens,
+		Name:              "send contacts",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Bearer:            config.AccessToken,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, MessageEndpoint),
 	}
 
 	return client.bc.Send(ctx, req, contact)
@@ -545,8 +609,10 @@ func (client *Client) SendContacts(ctx context.Context, recipient string, contac
 
 // SendLocation sends a location message to a WhatsApp Business Account.
 func (client *Client) SendLocation(ctx context.Context, recipient string,
-	message *models.Location,
+	message *models.Location, opts ...CallOption,
 ) (*ResponseMessage, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	location := &models.Message{
 		Product:       MessagingProduct,
 		To:            recipient,
@@ -561,41 +627,53 @@ func (client *Client) SendLocation(ctx context.Context, recipient string,
 	}
 
 	req := &whttp.RequestContext{
-		Name:          "send location",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Bearer:        client.config.AccessToken,
-		Endpoints:     []string{MessageEndpoint},
+		TokenSource:       client.tokens,
+		Name:              "send location",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Bearer:            config.AccessToken,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, MessageEndpoint),
 	}
 
 	return client.bc.Send(ctx, req, location)
 }
 
 // SendMessage sends a message.
-func (client *Client) SendMessage(ctx context.Context, name string, message *models.Message) (
+func (client *Client) SendMessage(ctx context.Context, name string, message *models.Message, opts ...CallOption) (
 	*ResponseMessage, error,
 ) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	req := &whttp.RequestContext{
-		Name:          name,
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Bearer:        client.config.AccessToken,
-		Endpoints:     []string{MessageEndpoint},
+		TokenSource:       client.tokens,
+		Name:              name,
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Bearer:            config.AccessToken,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, MessageEndpoint),
 	}
 
 	return client.bc.Send(ctx, req, message)
 }
 
 // MarkMessageRead sends a read receipt for a message.
-func (client *Client) MarkMessageRead(ctx context.Context, messageID string) (*StatusResponse, error) {
+func (client *Client) MarkMessageRead(ctx context.Context, messageID string,
+	opts ...CallOption,
+) (*StatusResponse, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	req := &whttp.RequestContext{
-		Name:          "mark message read",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Endpoints:     []string{MessageEndpoint},
+		TokenSource:       client.tokens,
+		Name:              "mark message read",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, MessageEndpoint),
 	}
 
 	return client.bc.MarkMessageRead(ctx, req, messageID)
@@ -603,9 +681,13 @@ func (client *Client) MarkMessageRead(ctx context.Context, messageID string) (*S
 
 // SendMediaTemplate sends a media template message to the recipient. This kind of template message has a media
 // message as a header. This is its main distinguishing feature from the text based template message.
-func (client *Client) SendMediaTemplate(ctx context.Context, recipient string, req *MediaTemplateRequest) (
+func (client *Client) SendMediaTemplate(ctx context.Context, recipient string, req *MediaTemplateRequest,
+	opts ...CallOption,
+) (
 	*ResponseMessage, error,
 ) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	tmpLanguage := &models.TemplateLanguage{
 		Policy: req.LanguagePolicy,
 		Code:   req.LanguageCode,
@@ -620,11 +702,13 @@ func (client *Client) SendMediaTemplate(ctx context.Context, recipient string, r
 	}
 
 	reqCtx := &whttp.RequestContext{
-		Name:          "send media template",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Endpoints:     []string{"messages"},
+		TokenSource:       client.tokens,
+		Name:              "send media template",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, "messages"),
 	}
 
 	params := &whttp.Request{
@@ -634,11 +718,11 @@ func (client *Client) SendMediaTemplate(ctx context.Context, recipient string, r
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-		Bearer: client.config.AccessToken,
+		Bearer: config.AccessToken,
 	}
 
 	var message ResponseMessage
-	err := client.bc.base.Do(ctx, params, &message)
+	err := client.bc.do(ctx, params, &message)
 	if err != nil {
 		return nil, fmt.Errorf("client: send media template: %w", err)
 	}
@@ -648,9 +732,13 @@ func (client *Client) SendMediaTemplate(ctx context.Context, recipient string, r
 
 // SendTextTemplate sends a text template message to the recipient. This kind of template message has a text
 // message as a header. This is its main distinguishing feature from the media based template message.
-func (client *Client) SendTextTemplate(ctx context.Context, recipient string, req *TextTemplateRequest) (
+func (client *Client) SendTextTemplate(ctx context.Context, recipient string, req *TextTemplateRequest,
+	opts ...CallOption,
+) (
 	*ResponseMessage, error,
 ) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	tmpLanguage := &models.TemplateLanguage{
 		Policy: req.LanguagePolicy,
 		Code:   req.LanguageCode,
@@ -658,11 +746,13 @@ func (client *Client) SendTextTemplate(ctx context.Context, recipient string, re
 	template := models.NewTextTemplate(req.Name, tmpLanguage, req.Body)
 	payload := models.NewMessage(recipient, models.WithTemplate(template))
 	reqCtx := &whttp.RequestContext{
-		Name:          "send text template",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Endpoints:     []string{"messages"},
+		TokenSource:       client.tokens,
+		Name:              "send text template",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, "messages"),
 	}
 
 	params := &whttp.Request{
@@ -672,11 +762,11 @@ func (client *Client) SendTextTemplate(ctx context.Context, recipient string, re
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-		Bearer: client.config.AccessToken,
+		Bearer: config.AccessToken,
 	}
 
 	var message ResponseMessage
-	err := client.bc.base.Do(ctx, params, &message)
+	err := client.bc.do(ctx, params, &message)
 	if err != nil {
 		return nil, fmt.Errorf("client: send text template: %w", err)
 	}
@@ -690,9 +780,11 @@ func (client *Client) SendTextTemplate(ctx context.Context, recipient string, re
 // can have any of the above as a Header and also have a list of buttons that the user can interact with.
 // You can use models.NewTextTemplate, models.NewMediaTemplate and models.NewInteractiveTemplate to create a Template.
 // These are helper functions that will make your life easier.
-func (client *Client) SendTemplate(ctx context.Context, recipient string, template *Template) (
+func (client *Client) SendTemplate(ctx context.Context, recipient string, template *Template, opts ...CallOption) (
 	*ResponseMessage, error,
 ) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	message := &models.Message{
 		Product:       MessagingProduct,
 		To:            recipient,
@@ -709,21 +801,27 @@ func (client *Client) SendTemplate(ctx context.Context, recipient string, templa
 	}
 
 	req := &whttp.RequestContext{
-		Name:          "send message",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Bearer:        client.config.AccessToken,
-		Endpoints:     []string{"messages"},
+		TokenSource:       client.tokens,
+		Name:              "send message",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Bearer:            config.AccessToken,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, "messages"),
 	}
 
 	return client.bc.Send(ctx, req, message)
 }
 
 // SendInteractiveMessage sends an interactive message to the recipient.
-func (client *Client) SendInteractiveMessage(ctx context.Context, recipient string, req *models.Interactive) (
+func (client *Client) SendInteractiveMessage(ctx context.Context, recipient string, req *models.Interactive,
+	opts ...CallOption,
+) (
 	*ResponseMessage, error,
 ) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	template := &models.Message{
 		Product:       MessagingProduct,
 		To:            recipient,
@@ -733,12 +831,14 @@ func (client *Client) SendInteractiveMessage(ctx context.Context, recipient stri
 	}
 
 	reqc := &whttp.RequestContext{
-		Name:          "send interactive message",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Bearer:        client.config.AccessToken,
-		Endpoints:     []string{"messages"},
+		TokenSource:       client.tokens,
+		Name:              "send interactive message",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Bearer:            config.AccessToken,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, "messages"),
 	}
 
 	return client.bc.Send(ctx, reqc, template)
@@ -748,21 +848,24 @@ func (client *Client) SendInteractiveMessage(ctx context.Context, recipient stri
 // first upload your media asset to our servers and capture the returned media ID. If using link, your asset must
 // be on a publicly accessible server or the message will fail to send.
 func (client *Client) SendMedia(ctx context.Context, recipient string, req *MediaMessage,
-	cacheOptions *CacheOptions,
+	cacheOptions *CacheOptions, opts ...CallOption,
 ) (*ResponseMessage, error) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	request := &SendMediaRequest{
-		BaseURL:       client.config.BaseURL,
-		AccessToken:   client.config.AccessToken,
-		PhoneNumberID: client.config.PhoneNumberID,
-		ApiVersion:    client.config.Version,
-		Recipient:     recipient,
-		Type:          req.Type,
-		MediaID:       req.MediaID,
-		MediaLink:     req.MediaLink,
-		Caption:       req.Caption,
-		Filename:      req.Filename,
-		Provider:      req.Provider,
-		CacheOptions:  cacheOptions,
+		BaseURL:           config.BaseURL,
+		AccessToken:       config.AccessToken,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		ApiVersion:        config.Version,
+		Recipient:         recipient,
+		Type:              req.Type,
+		MediaID:           req.MediaID,
+		MediaLink:         req.MediaLink,
+		Caption:           req.Caption,
+		Filename:          req.Filename,
+		Provider:          req.Provider,
+		CacheOptions:      cacheOptions,
 	}
 
 	payload, err := formatMediaPayload(request)
@@ -771,11 +874,13 @@ func (client *Client) SendMedia(ctx context.Context, recipient string, req *Medi
 	}
 
 	reqCtx := &whttp.RequestContext{
-		Name:          "send media",
-		BaseURL:       request.BaseURL,
-		ApiVersion:    request.ApiVersion,
-		PhoneNumberID: request.PhoneNumberID,
-		Endpoints:     []string{"messages"},
+		TokenSource:       client.tokens,
+		Name:              "send media",
+		BaseURL:           request.BaseURL,
+		ApiVersion:        request.ApiVersion,
+		PhoneNumberID:     request.PhoneNumberID,
+		BusinessAccountID: request.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(request.PhoneNumberID, "messages"),
 	}
 
 	params := &whttp.Request{
@@ -802,7 +907,7 @@ func (client *Client) SendMedia(ctx context.Context, recipient string, req *Medi
 
 	var message ResponseMessage
 
-	err = client.bc.base.Do(ctx, params, &message)
+	err = client.bc.do(ctx, params, &message)
 	if err != nil {
 		return nil, fmt.Errorf("send media: %w", err)
 	}
@@ -820,9 +925,13 @@ func (client *Client) SendMedia(ctx context.Context, recipient string, req *Medi
 //
 // These buttons can be attached to text messages or media messages. Once your interactive message templates have been
 // created and approved, you can use them in notification messages as well as customer service/care messages.
-func (client *Client) SendInteractiveTemplate(ctx context.Context, recipient string, req *InteractiveTemplateRequest) (
+func (client *Client) SendInteractiveTemplate(ctx context.Context, recipient string, req *InteractiveTemplateRequest,
+	opts ...CallOption,
+) (
 	*ResponseMessage, error,
 ) {
+	ctx = client.withCallOptions(ctx, opts)
+	config := client.config()
 	tmpLanguage := &models.TemplateLanguage{
 		Policy: req.LanguagePolicy,
 		Code:   req.LanguageCode,
@@ -836,11 +945,13 @@ func (client *Client) SendInteractiveTemplate(ctx context.Context, recipient str
 		Template:      template,
 	}
 	reqCtx := &whttp.RequestContext{
-		Name:          "send template",
-		BaseURL:       client.config.BaseURL,
-		ApiVersion:    client.config.Version,
-		PhoneNumberID: client.config.PhoneNumberID,
-		Endpoints:     []string{"messages"},
+		TokenSource:       client.tokens,
+		Name:              "send template",
+		BaseURL:           config.BaseURL,
+		ApiVersion:        config.Version,
+		PhoneNumberID:     config.PhoneNumberID,
+		BusinessAccountID: config.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(config.PhoneNumberID, "messages"),
 	}
 	params := &whttp.Request{
 		Method:  http.MethodPost,
@@ -849,10 +960,10 @@ func (client *Client) SendInteractiveTemplate(ctx context.Context, recipient str
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-		Bearer: client.config.AccessToken,
+		Bearer: config.AccessToken,
 	}
 	var message ResponseMessage
-	err := client.bc.base.Do(ctx, params, &message)
+	err := client.bc.do(ctx, params, &message)
 	if err != nil {
 		return nil, fmt.Errorf("send template: %w", err)
 	}
@@ -862,13 +973,19 @@ func (client *Client) SendInteractiveTemplate(ctx context.Context, recipient str
 
 // Whatsapp is an interface that represents a whatsapp client.
 type Whatsapp interface {
-	SendText(ctx context.Context, recipient string, message *TextMessage) (*ResponseMessage, error)
-	React(ctx context.Context, recipient string, msg *ReactMessage) (*ResponseMessage, error)
-	SendContacts(ctx context.Context, recipient string, contacts []*models.Contact) (*ResponseMessage, error)
-	SendLocation(ctx context.Context, recipient string, location *models.Location) (*ResponseMessage, error)
-	SendInteractiveMessage(ctx context.Context, recipient string, req *models.Interactive) (*ResponseMessage, error)
-	SendTemplate(ctx context.Context, recipient string, template *Template) (*ResponseMessage, error)
-	SendMedia(ctx context.Context, recipient string, media *MediaMessage, options *CacheOptions) (*ResponseMessage, error)
+	SendText(ctx context.Context, recipient string, message *TextMessage, opts ...CallOption) (
+		*ResponseMessage, error)
+	React(ctx context.Context, recipient string, msg *ReactMessage, opts ...CallOption) (*ResponseMessage, error)
+	SendContacts(ctx context.Context, recipient string, contacts []*models.Contact, opts ...CallOption) (
+		*ResponseMessage, error)
+	SendLocation(ctx context.Context, recipient string, location *models.Location, opts ...CallOption) (
+		*ResponseMessage, error)
+	SendInteractiveMessage(ctx context.Context, recipient string, req *models.Interactive, opts ...CallOption) (
+		*ResponseMessage, error)
+	SendTemplate(ctx context.Context, recipient string, template *Template, opts ...CallOption) (
+		*ResponseMessage, error)
+	SendMedia(ctx context.Context, recipient string, media *MediaMessage, options *CacheOptions,
+		opts ...CallOption) (*ResponseMessage, error)
 }
 
 var _ Whatsapp = (*Client)(nil)
@@ -878,9 +995,17 @@ type (
 	// It does not have the context. This is idealy for making requests to the whatsapp api for
 	// different users. The Client struct is used to make requests to the whatsapp api for a
 	// single user.
+	//
+	// Every call made by a BaseClient passes through its OperationMiddleware, message sends
+	// also pass through its SendMiddleware first.
 	BaseClient struct {
-		base *whttp.Client
-		mw   []SendMiddleware
+		base       *whttp.Client
+		mw         []SendMiddleware
+		opmw       []OperationMiddleware
+		codec      codec.Codec
+		logger     *slog.Logger
+		logOptions []redact.Option
+		metrics    *metrics.Registry
 	}
 
 	// BaseClientOption is a function that implements the BaseClientOption interface.
@@ -901,6 +1026,15 @@ func WithBaseHTTPClient(httpClient *whttp.Client) BaseClientOption {
 	}
 }
 
+// WithBaseClientCodec sets the codec that encodes the payloads and decodes the responses
+// of the base client, including batch results and graph pages. It is also set on the http
+// client of the base client, so a client passed with WithBaseHTTPClient is modified.
+func WithBaseClientCodec(c codec.Codec) BaseClientOption {
+	return func(client *BaseClient) {
+		client.codec = c
+	}
+}
+
 // NewBaseClient creates a new base client.
 func NewBaseClient(options ...BaseClientOption) *BaseClient {
 	b := &BaseClient{base: whttp.NewClient()}
@@ -909,6 +1043,18 @@ func NewBaseClient(options ...BaseClientOption) *BaseClient {
 		option(b)
 	}
 
+	if b.codec != nil {
+		b.base.SetCodec(b.codec)
+	}
+
+	if b.metrics != nil {
+		b.setMetrics(b.metrics)
+	}
+
+	if b.logger != nil {
+		b.setLogger(b.logger, b.logOptions...)
+	}
+
 	return b
 }
 
@@ -929,11 +1075,12 @@ func (c *BaseClient) SendTemplate(ctx context.Context, req *SendTemplateRequest,
 		},
 	}
 	reqCtx := &whttp.RequestContext{
-		Name:          "send template",
-		BaseURL:       req.BaseURL,
-		ApiVersion:    req.ApiVersion,
-		PhoneNumberID: req.PhoneNumberID,
-		Endpoints:     []string{"messages"},
+		Name:              "send template",
+		BaseURL:           req.BaseURL,
+		ApiVersion:        req.ApiVersion,
+		PhoneNumberID:     req.PhoneNumberID,
+		BusinessAccountID: req.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(req.PhoneNumberID, "messages"),
 	}
 	params := &whttp.Request{
 		Method:  http.MethodPost,
@@ -942,10 +1089,11 @@ func (c *BaseClient) SendTemplate(ctx context.Context, req *SendTemplateRequest,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-		Bearer: req.AccessToken,
+		Bearer:      req.AccessToken,
+		TokenSource: req.TokenSource,
 	}
 	var message ResponseMessage
-	err := c.base.Do(ctx, params, &message)
+	err := c.do(ctx, params, &message)
 	if err != nil {
 		return nil, fmt.Errorf("send template: %w", err)
 	}
@@ -1030,19 +1178,21 @@ func (c *BaseClient) SendMedia(ctx context.Context, req *SendMediaRequest,
 	}
 
 	reqCtx := &whttp.RequestContext{
-		Name:          "send media",
-		BaseURL:       req.BaseURL,
-		ApiVersion:    req.ApiVersion,
-		PhoneNumberID: req.PhoneNumberID,
-		Endpoints:     []string{"messages"},
+		Name:              "send media",
+		BaseURL:           req.BaseURL,
+		ApiVersion:        req.ApiVersion,
+		PhoneNumberID:     req.PhoneNumberID,
+		BusinessAccountID: req.BusinessAccountID,
+		Route:             whttp.PhoneNumberRoute(req.PhoneNumberID, "messages"),
 	}
 
 	params := &whttp.Request{
-		Context: reqCtx,
-		Method:  http.MethodPost,
-		Bearer:  req.AccessToken,
-		Headers: map[string]string{"Content-Type": "application/json"},
-		Payload: payload,
+		Context:     reqCtx,
+		Method:      http.MethodPost,
+		Bearer:      req.AccessToken,
+		TokenSource: req.TokenSource,
+		Headers:     map[string]string{"Content-Type": "application/json"},
+		Payload:     payload,
 	}
 
 	if req.CacheOptions != nil {
@@ -1061,7 +1211,7 @@ func (c *BaseClient) SendMedia(ctx context.Context, req *SendMediaRequest,
 
 	var message ResponseMessage
 
-	err = c.base.Do(ctx, params, &message)
+	err = c.do(ctx, params, &message)
 	if err != nil {
 		return nil, fmt.Errorf("send media: %w", err)
 	}
@@ -1125,7 +1275,7 @@ func (c *BaseClient) send(ctx context.Context, req *whttp.RequestContext,
 	}
 
 	var resp ResponseMessage
-	err := c.base.Do(ctx, request, &resp)
+	err := c.do(ctx, request, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", req.Name, err)
 	}
@@ -1151,7 +1301,7 @@ func (c *BaseClient) MarkMessageRead(ctx context.Context, req *whttp.RequestCont
 	}
 
 	var success StatusResponse
-	err := c.base.Do(ctx, params, &success)
+	err := c.do(ctx, params, &success)
 	if err != nil {
 		return nil, fmt.Errorf("mark message read: %w", err)
 	}